@@ -0,0 +1,211 @@
+package fs
+
+import (
+	"bytes"
+	"io"
+	"os"
+)
+
+// CompareMethod represents how CompareDirs compares
+// the regular files present in both directories.
+type CompareMethod int
+
+const (
+	// CompareSizeModTime compares files by size and modification time.
+	CompareSizeModTime CompareMethod = iota
+
+	// CompareContents compares files by size and byte-by-byte contents.
+	CompareContents
+
+	// CompareHash compares files by size and SHA-256 digest of their contents.
+	CompareHash
+)
+
+// CompareDirsOptions represents the options available for comparing directories.
+type CompareDirsOptions struct {
+	// ReadDirOptions specifies the files compared in both directories.
+	// Sort, MaxFiles, SkipStat and Hash options are ignored.
+	ReadDirOptions *ReadDirOptions
+
+	// Method specifies how regular files present in both directories are compared.
+	Method CompareMethod
+}
+
+// DirComparison represents the differences between two directories.
+type DirComparison struct {
+	OnlyInA   []*FileInfo // files only in the first directory
+	OnlyInB   []*FileInfo // files only in the second directory
+	Different []*FilePair // files in both directories that differ
+}
+
+// FilePair represents a file present at the same relative path in two directories.
+type FilePair struct {
+	A *FileInfo // file in the first directory
+	B *FileInfo // file in the second directory
+}
+
+// CompareDirs reads the directories named by the given dirnames a and b
+// following the given options and returns the files present in only one of them
+// and the files present in both that differ, sorted by path.
+// Files differ if they have different types or, for regular files,
+// different sizes or if they differ following the given method.
+// Symbolic links differ if they have different targets.
+// Errors are handled as specified by the ReadDirOptions and are returned
+// together with the differences found.
+func CompareDirs(a, b string, options *CompareDirsOptions) (*DirComparison, error) {
+	if options == nil || options.ReadDirOptions == nil {
+		return nil, NoReadDirOptionsErr
+	}
+
+	if overlapping, err := overlappingDirs(a, b); err != nil {
+		return nil, err
+	} else if overlapping {
+		return nil, OverlappingDirsErr
+	}
+
+	readOptions := pathOrderOptions(options.ReadDirOptions)
+	readOptions.SkipStat = false
+
+	handler := errorHandler{options: readOptions}
+	fileInfosA, err := ReadDir(a, readOptions)
+	handler.add(err)
+	if handler.aborted() {
+		return &DirComparison{}, handler.err()
+	}
+	fileInfosB, err := ReadDir(b, readOptions)
+	handler.add(err)
+	if handler.aborted() {
+		return &DirComparison{}, handler.err()
+	}
+
+	filesB := make(map[string]*FileInfo, len(fileInfosB))
+	for _, fi := range fileInfosB {
+		filesB[fi.RelPath] = fi
+	}
+
+	comparison := &DirComparison{}
+	found := make(map[string]bool, len(fileInfosA))
+	for _, fiA := range fileInfosA {
+		fiB, ok := filesB[fiA.RelPath]
+		if !ok {
+			comparison.OnlyInA = append(comparison.OnlyInA, fiA)
+			continue
+		}
+		found[fiA.RelPath] = true
+
+		same, err := sameFiles(fiA, fiB, options.Method)
+		if err != nil {
+			if handler.handle(fiA.Path, err) {
+				return comparison, handler.err()
+			}
+			continue
+		}
+		if !same {
+			comparison.Different = append(comparison.Different, &FilePair{A: fiA, B: fiB})
+		}
+	}
+
+	for _, fiB := range fileInfosB {
+		if !found[fiB.RelPath] {
+			comparison.OnlyInB = append(comparison.OnlyInB, fiB)
+		}
+	}
+
+	return comparison, handler.err()
+}
+
+// overlappingDirs returns true if the given directories are the same directory
+// or if one is a subdirectory of the other.
+func overlappingDirs(dirname1, dirname2 string) (bool, error) {
+	same, err := SameDir(dirname1, dirname2)
+	if err != nil || same {
+		return same, err
+	}
+
+	subdir, err := SubdirOf(dirname1, dirname2)
+	if err != nil || subdir {
+		return subdir, err
+	}
+
+	return SubdirOf(dirname2, dirname1)
+}
+
+// sameFiles returns true if the given files are equal following the given method.
+func sameFiles(fi1, fi2 *FileInfo, method CompareMethod) (bool, error) {
+	if fi1.Type != fi2.Type {
+		return false, nil
+	}
+
+	switch {
+	case fi1.IsSymlink():
+		target1, err := os.Readlink(fi1.Path)
+		if err != nil {
+			return false, err
+		}
+		target2, err := os.Readlink(fi2.Path)
+		if err != nil {
+			return false, err
+		}
+		return target1 == target2, nil
+	case !fi1.IsRegular():
+		return true, nil
+	case fi1.Size != fi2.Size:
+		return false, nil
+	}
+
+	switch method {
+	case CompareContents:
+		return sameContents(fi1.Path, fi2.Path)
+	case CompareHash:
+		hash1, err := fi1.Hash(SHA256)
+		if err != nil {
+			return false, err
+		}
+		hash2, err := fi2.Hash(SHA256)
+		if err != nil {
+			return false, err
+		}
+		return hash1 == hash2, nil
+	default:
+		return fi1.ModTime.Equal(fi2.ModTime), nil
+	}
+}
+
+// sameContents returns true if the files with the given filenames
+// have the same contents.
+func sameContents(filename1, filename2 string) (bool, error) {
+	file1, err := os.Open(filename1)
+	if err != nil {
+		return false, err
+	}
+	defer file1.Close()
+
+	file2, err := os.Open(filename2)
+	if err != nil {
+		return false, err
+	}
+	defer file2.Close()
+
+	buf1 := make([]byte, 32*1024)
+	buf2 := make([]byte, 32*1024)
+	for {
+		n1, err1 := io.ReadFull(file1, buf1)
+		eof1 := err1 == io.EOF || err1 == io.ErrUnexpectedEOF
+		if err1 != nil && !eof1 {
+			return false, err1
+		}
+
+		n2, err2 := io.ReadFull(file2, buf2)
+		eof2 := err2 == io.EOF || err2 == io.ErrUnexpectedEOF
+		if err2 != nil && !eof2 {
+			return false, err2
+		}
+
+		if !bytes.Equal(buf1[:n1], buf2[:n2]) {
+			return false, nil
+		}
+		if eof1 || eof2 {
+			return eof1 == eof2, nil
+		}
+	}
+}
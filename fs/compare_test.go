@@ -0,0 +1,179 @@
+package fs
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompareDirs(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "dir")
+	assert.Nil(err)
+	defer os.RemoveAll(dir)
+
+	modTime := time.Now().Add(-time.Hour)
+	dirA := filepath.Join(dir, "a")
+	dirB := filepath.Join(dir, "b")
+	for _, dirname := range []string{dirA, dirB} {
+		assert.Nil(os.MkdirAll(filepath.Join(dirname, "sub"), 0755))
+		assert.Nil(writeTestFile(filepath.Join(dirname, "same.gif"), 10, modTime))
+		assert.Nil(writeTestFile(filepath.Join(dirname, "sub", "same.txt"), 10, modTime))
+	}
+	// Same size and contents, different modification time.
+	assert.Nil(writeTestFile(filepath.Join(dirA, "touched.gif"), 10, modTime))
+	assert.Nil(writeTestFile(filepath.Join(dirB, "touched.gif"), 10, modTime.Add(time.Minute)))
+	// Same size and modification time, different contents.
+	assert.Nil(writeTestFile(filepath.Join(dirA, "edited.gif"), 10, modTime))
+	assert.Nil(ioutil.WriteFile(filepath.Join(dirB, "edited.gif"), []byte("0123456789"), defaultFilePermissions))
+	assert.Nil(os.Chtimes(filepath.Join(dirB, "edited.gif"), modTime, modTime))
+	// Different sizes.
+	assert.Nil(writeTestFile(filepath.Join(dirA, "sub", "size.txt"), 10, modTime))
+	assert.Nil(writeTestFile(filepath.Join(dirB, "sub", "size.txt"), 20, modTime))
+	// Different types.
+	assert.Nil(writeTestFile(filepath.Join(dirA, "type"), 0, modTime))
+	assert.Nil(os.Mkdir(filepath.Join(dirB, "type"), 0755))
+	// Only in one directory.
+	assert.Nil(writeTestFile(filepath.Join(dirA, "a.gif"), 10, modTime))
+	assert.Nil(writeTestFile(filepath.Join(dirB, "sub", "b.gif"), 10, modTime))
+
+	type args struct {
+		a       string
+		b       string
+		options *CompareDirsOptions
+	}
+	tests := []struct {
+		name          string
+		args          args
+		wantOnlyInA   []string
+		wantOnlyInB   []string
+		wantDifferent []string
+		wantErr       bool
+	}{
+		{
+			"nil options",
+			args{dirA, dirB, nil},
+			nil,
+			nil,
+			nil,
+			true,
+		},
+		{
+			"invalid dir",
+			args{dirA, filepath.Join(dir, "c"), &CompareDirsOptions{ReadDirOptions: &ReadDirOptions{}}},
+			nil,
+			nil,
+			nil,
+			true,
+		},
+		{
+			"same dir",
+			args{dirA, dirA + string(filepath.Separator), &CompareDirsOptions{ReadDirOptions: &ReadDirOptions{}}},
+			nil,
+			nil,
+			nil,
+			true,
+		},
+		{
+			"subdir",
+			args{dir, dirA, &CompareDirsOptions{ReadDirOptions: &ReadDirOptions{}}},
+			nil,
+			nil,
+			nil,
+			true,
+		},
+		{
+			"parent dir",
+			args{filepath.Join(dirB, "sub"), dirB, &CompareDirsOptions{ReadDirOptions: &ReadDirOptions{}}},
+			nil,
+			nil,
+			nil,
+			true,
+		},
+		{
+			"size and modification time",
+			args{
+				dirA,
+				dirB,
+				&CompareDirsOptions{
+					ReadDirOptions: &ReadDirOptions{
+						IncludeSubdirs: true,
+						IncludeDirs:    true,
+					},
+				},
+			},
+			[]string{"a.gif"},
+			[]string{"sub/b.gif"},
+			[]string{"sub/size.txt", "touched.gif", "type"},
+			false,
+		},
+		{
+			"contents",
+			args{
+				dirA,
+				dirB,
+				&CompareDirsOptions{
+					ReadDirOptions: &ReadDirOptions{
+						IncludeSubdirs: true,
+					},
+					Method: CompareContents,
+				},
+			},
+			[]string{"a.gif", "type"},
+			[]string{"sub/b.gif"},
+			[]string{"edited.gif", "sub/size.txt"},
+			false,
+		},
+		{
+			"hash, filtered",
+			args{
+				dirA,
+				dirB,
+				&CompareDirsOptions{
+					ReadDirOptions: &ReadDirOptions{
+						IncludeSubdirs: true,
+						Filter: func(fi *FileInfo) bool {
+							return fi.Ext == ".gif"
+						},
+					},
+					Method: CompareHash,
+				},
+			},
+			[]string{"a.gif"},
+			[]string{"sub/b.gif"},
+			[]string{"edited.gif"},
+			false,
+		},
+	}
+	for _, tt := range tests {
+		got, err := CompareDirs(tt.args.a, tt.args.b, tt.args.options)
+		if tt.wantErr {
+			assert.NotNil(err, tt.name)
+			assert.Nil(got, tt.name)
+			continue
+		}
+		assert.Nil(err, tt.name)
+		assert.Equal(tt.wantOnlyInA, relPaths(got.OnlyInA), tt.name)
+		assert.Equal(tt.wantOnlyInB, relPaths(got.OnlyInB), tt.name)
+
+		var gotDifferent []string
+		for _, pair := range got.Different {
+			assert.Equal(pair.A.RelPath, pair.B.RelPath, tt.name)
+			gotDifferent = append(gotDifferent, pair.A.RelPath)
+		}
+		assert.Equal(tt.wantDifferent, gotDifferent, tt.name)
+	}
+}
+
+func relPaths(fileInfos []*FileInfo) []string {
+	var paths []string
+	for _, fi := range fileInfos {
+		paths = append(paths, fi.RelPath)
+	}
+	return paths
+}
@@ -0,0 +1,105 @@
+package fs
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// sniffLen is the number of bytes read to detect content types.
+const sniffLen = 512
+
+// contentSignature represents the magic bytes identifying a content type.
+type contentSignature struct {
+	offset      int
+	magic       []byte
+	contentType string
+}
+
+// contentSignatures holds the signatures of formats
+// not detected by http.DetectContentType.
+var contentSignatures = []contentSignature{
+	{0, []byte("7z\xBC\xAF\x27\x1C"), "application/x-7z-compressed"},
+	{0, []byte("BZh"), "application/x-bzip2"},
+	{0, []byte("\xFD7zXZ\x00"), "application/x-xz"},
+	{0, []byte("\x28\xB5\x2F\xFD"), "application/zstd"},
+	{257, []byte("ustar"), "application/x-tar"},
+	{0, []byte("II*\x00"), "image/tiff"},
+	{0, []byte("MM\x00*"), "image/tiff"},
+	{0, []byte("fLaC"), "audio/flac"},
+}
+
+// DetectContentType returns the MIME type of the file with the given filename
+// detected from its first bytes, such as "image/png" or "application/pdf".
+// Text files are detected as "text/plain; charset=utf-8" or, for markup,
+// as "text/html" or "text/xml", while unknown binary files are detected
+// as "application/octet-stream".
+func DetectContentType(filename string) (string, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	buf := make([]byte, sniffLen)
+	n, err := io.ReadFull(file, buf)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return "", err
+	}
+
+	return detectContentType(buf[:n]), nil
+}
+
+// detectContentType returns the MIME type of the given leading bytes of a file.
+func detectContentType(data []byte) string {
+	for _, sig := range contentSignatures {
+		end := sig.offset + len(sig.magic)
+		if len(data) >= end && bytes.Equal(data[sig.offset:end], sig.magic) {
+			return sig.contentType
+		}
+	}
+
+	return http.DetectContentType(data)
+}
+
+// DetectContentType returns the MIME type detected from the file contents,
+// as described by the package-level DetectContentType.
+// The content type is cached in ContentType and detected only if not already set.
+func (fi *FileInfo) DetectContentType() (string, error) {
+	if fi.ContentType != "" {
+		return fi.ContentType, nil
+	}
+
+	contentType, err := DetectContentType(fi.Path)
+	if err != nil {
+		return "", err
+	}
+
+	fi.ContentType = contentType
+	return contentType, nil
+}
+
+// matchContentType returns true if the given content type
+// matches one of the given patterns, which are either media types,
+// such as "image/png", or type prefixes ending with a slash, such as "image/".
+// Parameters, such as charsets, are ignored.
+func matchContentType(contentType string, patterns []string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = contentType
+	}
+
+	for _, pattern := range patterns {
+		pattern = strings.ToLower(pattern)
+		if strings.HasSuffix(pattern, "/") && strings.HasPrefix(mediaType, pattern) {
+			return true
+		}
+		if mediaType == pattern {
+			return true
+		}
+	}
+	return false
+}
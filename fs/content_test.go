@@ -0,0 +1,174 @@
+package fs
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetectContentType(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "dir")
+	assert.Nil(err)
+	defer os.RemoveAll(dir)
+
+	tar := make([]byte, 512)
+	copy(tar, "file.txt")
+	copy(tar[257:], "ustar\x0000")
+
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"empty", nil, "text/plain; charset=utf-8"},
+		{"text", []byte("hello\n"), "text/plain; charset=utf-8"},
+		{"binary", []byte("\x00\x01\x02\x03"), "application/octet-stream"},
+		{"html", []byte("<!DOCTYPE html><html><body>Not Found</body></html>"), "text/html; charset=utf-8"},
+		{"gif", []byte("GIF89a\x01\x00\x01\x00"), "image/gif"},
+		{"png", []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR"), "image/png"},
+		{"jpeg", []byte("\xff\xd8\xff\xe0\x00\x10JFIF"), "image/jpeg"},
+		{"tiff", []byte("II*\x00\x08\x00\x00\x00"), "image/tiff"},
+		{"pdf", []byte("%PDF-1.4\n"), "application/pdf"},
+		{"zip", []byte("PK\x03\x04\x14\x00"), "application/zip"},
+		{"gzip", []byte("\x1f\x8b\x08\x00"), "application/x-gzip"},
+		{"7z", []byte("7z\xbc\xaf\x27\x1c\x00\x04"), "application/x-7z-compressed"},
+		{"xz", []byte("\xfd7zXZ\x00\x00"), "application/x-xz"},
+		{"tar", tar, "application/x-tar"},
+		{"flac", []byte("fLaC\x00\x00\x00\x22"), "audio/flac"},
+		{"wav", []byte("RIFF\x24\x00\x00\x00WAVEfmt "), "audio/wave"},
+		{"mp4", []byte("\x00\x00\x00\x18ftypmp42\x00\x00\x00\x00mp42isom"), "video/mp4"},
+	}
+	for _, tt := range tests {
+		filename := filepath.Join(dir, tt.name)
+		assert.Nil(ioutil.WriteFile(filename, tt.data, defaultFilePermissions), tt.name)
+
+		got, err := DetectContentType(filename)
+		assert.Nil(err, tt.name)
+		assert.Equal(tt.want, got, tt.name)
+	}
+
+	_, err = DetectContentType(filepath.Join(dir, "missing"))
+	assert.NotNil(err)
+}
+
+func TestFileInfo_DetectContentType(t *testing.T) {
+	assert := assert.New(t)
+
+	wd, err := os.Getwd()
+	assert.Nil(err)
+	filename := filepath.Join(filepath.Dir(wd), "testdata", "read_dir_test", "dir1", "40.gif")
+
+	fi, err := ReadFileInfo(filename)
+	assert.Nil(err)
+	assert.Equal("", fi.ContentType)
+
+	got, err := fi.DetectContentType()
+	assert.Nil(err)
+	assert.Equal("image/gif", got)
+	assert.Equal("image/gif", fi.ContentType)
+
+	fi = newPathInfo(filepath.Join(wd, "missing.gif"), 0)
+	_, err = fi.DetectContentType()
+	assert.NotNil(err)
+	assert.Equal("", fi.ContentType)
+}
+
+func Test_matchContentType(t *testing.T) {
+	assert := assert.New(t)
+
+	tests := []struct {
+		contentType string
+		patterns    []string
+		want        bool
+	}{
+		{"image/png", []string{"image/png"}, true},
+		{"image/png", []string{"IMAGE/PNG"}, true},
+		{"image/png", []string{"image/"}, true},
+		{"image/png", []string{"image"}, false},
+		{"image/png", []string{"image/gif", "image/jpeg"}, false},
+		{"text/plain; charset=utf-8", []string{"text/plain"}, true},
+		{"text/html; charset=utf-8", []string{"text/"}, true},
+		{"application/octet-stream", []string{"text/", "image/"}, false},
+		{"application/octet-stream", nil, false},
+	}
+	for _, tt := range tests {
+		got := matchContentType(tt.contentType, tt.patterns)
+		assert.Equal(tt.want, got, "%s %v", tt.contentType, tt.patterns)
+	}
+}
+
+func TestReadDir_contentTypes(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "dir")
+	assert.Nil(err)
+	defer os.RemoveAll(dir)
+	assert.Nil(os.Mkdir(filepath.Join(dir, "sub"), 0755))
+	files := map[string]string{
+		"gif.gif":  "GIF89a\x01\x00\x01\x00",
+		"png.gif":  "\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR",
+		"html.gif": "<html><body>Not Found</body></html>",
+		"text.txt": "hello\n",
+	}
+	for name, data := range files {
+		assert.Nil(ioutil.WriteFile(filepath.Join(dir, name), []byte(data), defaultFilePermissions))
+	}
+
+	tests := []struct {
+		name             string
+		options          *ReadDirOptions
+		want             []string
+		wantContentTypes []string
+	}{
+		{
+			"no content types",
+			&ReadDirOptions{},
+			[]string{"gif.gif", "html.gif", "png.gif", "text.txt"},
+			[]string{"", "", "", ""},
+		},
+		{
+			"images",
+			&ReadDirOptions{
+				ContentTypes: []string{"image/"},
+			},
+			[]string{"gif.gif", "png.gif"},
+			[]string{"image/gif", "image/png"},
+		},
+		{
+			"text, include dirs",
+			&ReadDirOptions{
+				IncludeDirs:  true,
+				ContentTypes: []string{"text/plain", "text/html"},
+			},
+			[]string{"html.gif", "sub", "text.txt"},
+			[]string{"text/html; charset=utf-8", "", "text/plain; charset=utf-8"},
+		},
+		{
+			"content types before filter",
+			&ReadDirOptions{
+				ContentTypes: []string{"image/"},
+				Filter: func(fi *FileInfo) bool {
+					return fi.ContentType != "image/gif"
+				},
+			},
+			[]string{"png.gif"},
+			[]string{"image/png"},
+		},
+	}
+	for _, tt := range tests {
+		got, err := ReadDir(dir, tt.options)
+		assert.Nil(err, tt.name)
+		assert.Equal(tt.want, relPaths(got), tt.name)
+
+		var gotContentTypes []string
+		for _, fi := range got {
+			gotContentTypes = append(gotContentTypes, fi.ContentType)
+		}
+		assert.Equal(tt.wantContentTypes, gotContentTypes, tt.name)
+	}
+}
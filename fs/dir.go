@@ -4,25 +4,187 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/karrick/godirwalk"
 )
 
+// ErrorPolicy represents how filesystem errors are handled when reading directories.
+type ErrorPolicy int
+
+const (
+	// IgnoreErrors skips the paths causing errors and ignores the errors.
+	IgnoreErrors ErrorPolicy = iota
+
+	// CollectErrors skips the paths causing errors and collects the errors,
+	// which are returned together with the files read as a MultiErr.
+	CollectErrors
+
+	// AbortOnError stops reading at the first error,
+	// which is returned together with the files read as a *PathErr.
+	AbortOnError
+)
+
+// HiddenPolicy represents how hidden files, whose names start with a dot,
+// are handled when reading directories.
+type HiddenPolicy int
+
+const (
+	// IncludeHidden reads hidden files and directories.
+	IncludeHidden HiddenPolicy = iota
+
+	// ExcludeHiddenFiles skips hidden files other than directories,
+	// while hidden directories are still read.
+	ExcludeHiddenFiles
+
+	// ExcludeHidden skips hidden files and directories,
+	// including the contents of hidden directories.
+	ExcludeHidden
+)
+
 // ReadDirOptions represents the options available for reading a directory.
 type ReadDirOptions struct {
 	// IncludeSubdirs, if true, specifies that subdirectories,
 	// at any nesting level, should also be read.
 	IncludeSubdirs bool
 
+	// IncludeDirs, if true, specifies that directories should also be read.
+	IncludeDirs bool
+
+	// IncludeSymlinks, if true, specifies that symbolic links
+	// should also be read.
+	IncludeSymlinks bool
+
+	// IncludeSpecial, if true, specifies that special files,
+	// such as named pipes, sockets and devices, should also be read.
+	IncludeSpecial bool
+
+	// OneFileSystem, if true, specifies that directories on filesystems
+	// other than the one of the read directory, such as mount points,
+	// should not be walked; the directories themselves are still read.
+	OneFileSystem bool
+
+	// Hidden specifies how hidden files and directories are handled.
+	Hidden HiddenPolicy
+
+	// FollowSymlinks, if true, specifies that symbolic links should be
+	// followed, reading the directories they point to and describing
+	// files by their targets. Each directory is read at most once,
+	// which also prevents cycles; when reading concurrently, the path
+	// through which a directory reachable in multiple ways is read
+	// is not deterministic.
+	FollowSymlinks bool
+
+	// MaxDepth, if greater than 0, specifies the maximum nesting level
+	// of the files to read, with files directly inside the read directory
+	// being at depth 1. MaxDepth has effect only if IncludeSubdirs is true.
+	MaxDepth int
+
+	// MinDepth, if greater than 0, specifies the minimum nesting level
+	// of the files to read.
+	MinDepth int
+
+	// SkipStat, if true, specifies that only the information available
+	// from directory entries, that is name, stem, extension, directory, path
+	// and type, should be read, which avoids reading each file's metadata.
+	// Files are still read when required by size or time filters or by Sort.
+	// Skipped information can be read later with FileInfo.Stat.
+	SkipStat bool
+
+	// Workers, if greater than 1, specifies the number of goroutines
+	// concurrently reading directories and files.
+	// When reading concurrently, all files are read and sorted
+	// before applying MaxFiles, unless Sort is NoSort,
+	// and Filter is called concurrently by multiple goroutines.
+	Workers int
+
+	// Hash, if not NoHash, specifies that the digests of the regular files
+	// read should be computed with the given algorithm and stored in Hashes.
+	// Digests are computed after reading, for the files returned only;
+	// files whose digests cannot be computed are returned without digests,
+	// after handling the errors as specified by ErrorPolicy.
+	Hash HashAlgorithm
+
+	// HashWorkers specifies the number of files hashed concurrently.
+	// If HashWorkers is not positive, the number of CPUs is used.
+	HashWorkers int
+
 	// MaxFiles specifies the maximum number of files to read.
+	// Only files satisfying the filters below are counted.
 	// If MaxFiles is 0, all files are read.
 	MaxFiles int
+
+	// MinSize, if greater than 0, specifies the minimum size
+	// in bytes of the regular files to read.
+	MinSize int64
+
+	// MaxSize, if greater than 0, specifies the maximum size
+	// in bytes of the regular files to read.
+	MaxSize int64
+
+	// ModifiedAfter, if not zero, specifies that only files
+	// modified after the given time should be read.
+	ModifiedAfter time.Time
+
+	// ModifiedBefore, if not zero, specifies that only files
+	// modified before the given time should be read.
+	ModifiedBefore time.Time
+
+	// ContentTypes, if not empty, specifies the MIME types of the regular
+	// files to read, detected from their contents as by DetectContentType.
+	// Types are either media types, such as "image/png", or prefixes
+	// ending with a slash, such as "image/".
+	// Detected types are stored in FileInfo.ContentType.
+	ContentTypes []string
+
+	// MinImageWidth and MinImageHeight, if greater than 0, specify
+	// the minimum dimensions in pixels of the regular files to read,
+	// which must then be GIF, PNG or JPEG images.
+	// Image metadata is stored in FileInfo.Image.
+	MinImageWidth  int
+	MinImageHeight int
+
+	// ReadExif, if true, specifies that the EXIF metadata of JPEG images
+	// should be read and stored in FileInfo.Exif before calling Filter.
+	// Files without valid EXIF metadata are read with a nil Exif.
+	ReadExif bool
+
+	// Filter, if not nil, is called for each file satisfying
+	// the filters above; only files for which Filter returns true are read.
+	Filter func(*FileInfo) bool
+
+	// Sort specifies the order of the files read.
+	// If Sort is not SortByPath or NoSort, or if SortDescending is true,
+	// all files are read and sorted before applying MaxFiles.
+	Sort SortOrder
+
+	// SortDescending, if true, specifies that files
+	// should be sorted in descending order.
+	SortDescending bool
+
+	// DirsFirst, if true, specifies that directories
+	// should come before other files, regardless of Sort.
+	DirsFirst bool
+
+	// ErrorPolicy specifies how filesystem errors are handled.
+	ErrorPolicy ErrorPolicy
+
+	// ErrorCallback, if not nil, is called with the path and the cause
+	// of each filesystem error; the returned policy is applied to the error
+	// in place of ErrorPolicy.
+	ErrorCallback func(pathname string, err error) ErrorPolicy
 }
 
 // ReadDir reads the directory named by the given dirname
 // following the given options and returns a list of FileInfo instances,
-// sorted by lexical path order, representing the regular files found.
-// Eventual filesystem errors are ignored.
+// sorted as specified by the options, representing the files found.
+// By default, only regular files are read.
+// Eventual filesystem errors are handled as specified by the options
+// and, by default, are ignored.
+// If errors are collected or the read is aborted,
+// ReadDir returns the files read together with the error.
 func ReadDir(dirname string, options *ReadDirOptions) ([]*FileInfo, error) {
 	if options == nil {
 		return nil, NoReadDirOptionsErr
@@ -35,46 +197,533 @@ func ReadDir(dirname string, options *ReadDirOptions) ([]*FileInfo, error) {
 	return readDir(dirname, options)
 }
 
+// pathOrderOptions returns a copy of the given options reading all files
+// in ascending path order, as needed to match files across directory reads.
+// Files are not hashed, leaving digests to be computed only when needed.
+func pathOrderOptions(options *ReadDirOptions) *ReadDirOptions {
+	readOptions := *options
+	readOptions.Sort = SortByPath
+	readOptions.SortDescending = false
+	readOptions.DirsFirst = false
+	readOptions.MaxFiles = 0
+	readOptions.Hash = NoHash
+	return &readOptions
+}
+
 func readDir(dirname string, options *ReadDirOptions) ([]*FileInfo, error) {
-	dirname = filepath.Clean(dirname)
-	skipSubdirs := !options.IncludeSubdirs
-	maxFiles := options.MaxFiles
-	limitFiles := maxFiles > 0
+	r := newDirReader(dirname, options, nil)
+	return r.read()
+}
 
-	fileInfos := make([]*FileInfo, 0, 1000)
-	_ = godirwalk.Walk(dirname, &godirwalk.Options{
-		Callback: func(osPathname string, de *godirwalk.Dirent) error {
-			osPathname = filepath.Clean(osPathname)
+// dirReader holds the state of a directory read.
+// Its methods can be called concurrently while walking.
+type dirReader struct {
+	dirname    string
+	options    *ReadDirOptions
+	maxDepth   int
+	limitDepth bool
+	concurrent bool
+	skipStat   bool
+	walkSorted bool
+	haltWalk   bool
 
-			skipDir := skipSubdirs && de.IsDir() && osPathname != dirname
-			if skipDir {
-				return filepath.SkipDir
+	// after, if not nil, is the file after which files should be read.
+	// If walkAfter is true, files up to after are skipped while walking.
+	after     *FileInfo
+	walkAfter bool
+
+	mu        sync.Mutex
+	fileInfos []*FileInfo
+	errors    errorHandler
+	halted    bool
+
+	// rootID identifies the read directory and is used
+	// when following symbolic links or staying on one filesystem.
+	rootID fileID
+
+	// visited and realDirs are used when following symbolic links
+	// and hold the identifiers and the real paths of the visited directories.
+	visited  map[fileID]bool
+	realDirs map[string]string
+}
+
+// newDirReader returns a reader for the directory with the given dirname.
+// If after is not nil, only files coming after it
+// in the order specified by the options are read.
+func newDirReader(dirname string, options *ReadDirOptions, after *FileInfo) *dirReader {
+	maxDepth := options.MaxDepth
+	if !options.IncludeSubdirs {
+		maxDepth = 1
+	}
+
+	// Files are found in the requested order only if they are not sorted
+	// or if they are sorted by ascending path order and read sequentially.
+	concurrent := options.Workers > 1
+	sortOrder := options.Sort
+	walkSorted := sortOrder == SortByPath && !options.SortDescending && !concurrent
+	walkSorted = walkSorted && !(options.DirsFirst && options.IncludeDirs)
+	walkSorted = walkSorted || (sortOrder == NoSort && !options.DirsFirst)
+
+	// Files up to after can be skipped while walking only if they are found
+	// in path order and each directory is reachable through a single path.
+	walkAfter := after != nil && walkSorted && sortOrder == SortByPath && !options.FollowSymlinks
+
+	// Files are stat'ed anyway if their size or modification time is needed.
+	skipStat := options.SkipStat &&
+		options.MinSize <= 0 && options.MaxSize <= 0 &&
+		options.ModifiedAfter.IsZero() && options.ModifiedBefore.IsZero() &&
+		sortOrder != SortBySize && sortOrder != SortByModTime
+
+	return &dirReader{
+		dirname:    filepath.Clean(dirname),
+		options:    options,
+		maxDepth:   maxDepth,
+		limitDepth: maxDepth > 0,
+		concurrent: concurrent,
+		skipStat:   skipStat,
+		walkSorted: walkSorted,
+		haltWalk:   options.MaxFiles > 0 && walkSorted && (after == nil || walkAfter),
+		after:      after,
+		walkAfter:  walkAfter,
+		fileInfos:  make([]*FileInfo, 0, 1000),
+		errors:     errorHandler{options: options},
+		visited:    make(map[fileID]bool),
+		realDirs:   make(map[string]string),
+	}
+}
+
+func (r *dirReader) read() ([]*FileInfo, error) {
+	options := r.options
+
+	if options.FollowSymlinks || options.OneFileSystem {
+		rootID, err := statFileID(r.dirname, nil)
+		if err != nil {
+			return nil, err
+		}
+		r.rootID = rootID
+		r.markVisited(rootID)
+	}
+
+	if options.FollowSymlinks {
+		realDirname, err := filepath.EvalSymlinks(r.dirname)
+		if err != nil {
+			return nil, err
+		}
+		r.realDirs[r.dirname] = realDirname
+	}
+
+	if r.concurrent {
+		r.walkConcurrent(options.Workers)
+	} else {
+		r.walk()
+	}
+
+	fileInfos := r.fileInfos
+	if !r.walkSorted {
+		sortFileInfos(fileInfos, options.Sort, options.SortDescending, options.DirsFirst)
+	}
+	if r.after != nil && !r.walkAfter {
+		fileInfos = filesAfter(fileInfos, r.after, options.Sort, options.SortDescending, options.DirsFirst)
+	}
+	if options.MaxFiles > 0 && len(fileInfos) > options.MaxFiles {
+		fileInfos = fileInfos[:options.MaxFiles]
+	}
+	if options.Hash != NoHash {
+		fileInfos = r.hashFiles(fileInfos)
+	}
+
+	return fileInfos, r.errors.err()
+}
+
+// hashFiles computes the digests of the given regular files and returns
+// the given files. Files whose digests cannot be computed are kept without
+// digests, so that the number of files read is unaffected,
+// unless the error stops reading.
+func (r *dirReader) hashFiles(fileInfos []*FileInfo) []*FileInfo {
+	var regular []*FileInfo
+	for _, fi := range fileInfos {
+		if fi.IsRegular() {
+			regular = append(regular, fi)
+		}
+	}
+
+	_, errs := hashFiles(regular, r.options.HashWorkers, func(fi *FileInfo) (string, error) {
+		return fi.Hash(r.options.Hash)
+	})
+
+	hashed := make([]*FileInfo, 0, len(fileInfos))
+	i := 0
+	for _, fi := range fileInfos {
+		if fi.IsRegular() {
+			err := errs[i]
+			i++
+			if err != nil && r.errors.handle(fi.Path, err) {
+				break
 			}
+		}
+		hashed = append(hashed, fi)
+	}
+
+	return hashed
+}
+
+// walk walks the directory sequentially.
+func (r *dirReader) walk() {
+	// A symbolic link given as root is walked through its target,
+	// as in walkConcurrent, with paths reported under the link.
+	root := r.dirname
+	if info, err := os.Lstat(root); err == nil && info.Mode()&os.ModeSymlink != 0 && !r.options.FollowSymlinks {
+		if root, err = filepath.EvalSymlinks(r.dirname); err != nil {
+			r.onError(r.dirname, err)
+			return
+		}
+	}
+	rootPath := func(osPathname string) string {
+		if root == r.dirname {
+			return osPathname
+		}
+		return r.dirname + strings.TrimPrefix(filepath.Clean(osPathname), root)
+	}
 
-			if de.IsRegular() {
-				fi, _ := ReadFileInfo(osPathname)
-				if fi != nil {
-					fileInfos = append(fileInfos, fi)
-				}
+	err := godirwalk.Walk(root, &godirwalk.Options{
+		Callback: func(osPathname string, de *godirwalk.Dirent) error {
+			osPathname = rootPath(osPathname)
+			isDir, descend, err := r.visit(osPathname, de)
+			if err != nil {
+				return err
 			}
 
-			halt := limitFiles && len(fileInfos) >= maxFiles
-			if halt {
-				return HaltErr
+			skipDir := isDir && !descend
+			if skipDir {
+				return filepath.SkipDir
 			}
 
 			return nil
 		},
-		ErrorCallback: func(_ string, err error) godirwalk.ErrorAction {
+		ErrorCallback: func(osPathname string, err error) godirwalk.ErrorAction {
 			if err == HaltErr {
 				return godirwalk.Halt
 			}
+			osPathname = rootPath(osPathname)
+			// Broken links are described by the links themselves
+			// when following symbolic links, as in visit.
+			if r.options.FollowSymlinks && isBrokenLink(osPathname) {
+				return godirwalk.SkipNode
+			}
 
-			return godirwalk.SkipNode
+			return r.onError(osPathname, err)
 		},
+		FollowSymbolicLinks: r.options.FollowSymlinks,
+		Unsorted:            r.options.Sort == NoSort,
 	})
 
-	return fileInfos, nil
+	// Errors not passed to ErrorCallback, such as those reading the root,
+	// are handled as the others.
+	if err != nil && err != HaltErr && !r.errors.aborted() {
+		r.onError(r.dirname, err)
+	}
+}
+
+// isBrokenLink returns true if the given pathname names a symbolic link
+// whose target cannot be found.
+func isBrokenLink(pathname string) bool {
+	info, err := os.Lstat(pathname)
+	if err != nil || info.Mode()&os.ModeSymlink == 0 {
+		return false
+	}
+	_, err = os.Stat(pathname)
+	return err != nil
+}
+
+// visit is called for each directory entry found during the walk
+// and returns whether the entry is a directory and whether it should be walked.
+func (r *dirReader) visit(osPathname string, de *godirwalk.Dirent) (isDir, descend bool, err error) {
+	options := r.options
+	osPathname = filepath.Clean(osPathname)
+	rel := relPath(r.dirname, osPathname)
+	if rel == "" {
+		return true, true, nil
+	}
+	depth := strings.Count(rel, string(filepath.Separator)) + 1
+
+	// When following symbolic links, entries are described by their targets,
+	// except for broken links, which are described by the links themselves.
+	entryType := de.ModeType()
+	var target os.FileInfo
+	if options.FollowSymlinks && (de.IsDir() || de.IsSymlink()) {
+		if info, err := os.Stat(osPathname); err == nil {
+			entryType = info.Mode() & os.ModeType
+			target = info
+		}
+	}
+
+	isDir = entryType&os.ModeDir != 0
+	hidden := isHidden(de.Name())
+	if hidden && options.Hidden == ExcludeHidden {
+		return isDir, false, nil
+	}
+
+	// Skip files up to after, walking only after and the directories containing it.
+	skipBefore := false
+	if r.walkAfter && comparePaths(osPathname, r.after.Path) <= 0 {
+		containsAfter := osPathname == r.after.Path ||
+			strings.HasPrefix(r.after.Path, osPathname+string(filepath.Separator))
+		if !isDir || !containsAfter {
+			return isDir, false, nil
+		}
+		skipBefore = true
+	}
+
+	descend = isDir && !(r.limitDepth && depth >= r.maxDepth)
+	if descend && (options.FollowSymlinks || options.OneFileSystem) {
+		id, err := statFileID(osPathname, target)
+		if err != nil {
+			return isDir, false, err
+		}
+
+		if options.OneFileSystem && id.dev != r.rootID.dev {
+			descend = false
+		}
+		if descend && options.FollowSymlinks {
+			descend = r.markVisited(id)
+		}
+	}
+
+	var realPath string
+	if options.FollowSymlinks {
+		realPath = r.realPath(osPathname, de, descend)
+	}
+
+	skipHidden := hidden && !isDir && options.Hidden == ExcludeHiddenFiles
+	if depth >= options.MinDepth && includeType(entryType, options) && !skipHidden && !skipBefore {
+		var fi *FileInfo
+		switch {
+		case target != nil:
+			fi = newFileInfo(osPathname, target)
+		case r.skipStat:
+			fi = newPathInfo(osPathname, entryType)
+		default:
+			if fi, err = readEntryInfo(osPathname); err != nil {
+				return isDir, false, err
+			}
+		}
+
+		fi.Root = r.dirname
+		fi.RelPath = filepath.ToSlash(rel)
+		fi.RealPath = realPath
+		fi.Depth = depth
+		match, err := matchFile(fi, options)
+		if err != nil {
+			return isDir, false, err
+		}
+		if match && !r.appendFile(fi) {
+			return isDir, false, HaltErr
+		}
+	}
+
+	return isDir, descend, nil
+}
+
+// appendFile appends the given file to the files read
+// and returns false if no more files should be read.
+func (r *dirReader) appendFile(fi *FileInfo) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.fileInfos = append(r.fileInfos, fi)
+	halt := r.haltWalk && len(r.fileInfos) >= r.options.MaxFiles
+	return !halt
+}
+
+// onError handles the given error following the options.
+func (r *dirReader) onError(pathname string, err error) godirwalk.ErrorAction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.errors.handle(pathname, err) {
+		return godirwalk.Halt
+	}
+	return godirwalk.SkipNode
+}
+
+// errorHandler handles errors following the error policy of the given options.
+type errorHandler struct {
+	options  *ReadDirOptions
+	errs     MultiErr
+	abortErr error
+}
+
+// handle handles the error found at the given pathname
+// and returns true if reading should stop.
+func (h *errorHandler) handle(pathname string, err error) bool {
+	policy := h.options.ErrorPolicy
+	if h.options.ErrorCallback != nil {
+		policy = h.options.ErrorCallback(pathname, err)
+	}
+
+	pathErr := &PathErr{Path: filepath.Clean(pathname), Err: err}
+	switch policy {
+	case CollectErrors:
+		h.errs = append(h.errs, pathErr)
+	case AbortOnError:
+		if h.abortErr == nil {
+			h.abortErr = pathErr
+		}
+		return true
+	}
+
+	return false
+}
+
+// add adds an error already handled, such as an error returned by ReadDir.
+func (h *errorHandler) add(err error) {
+	switch err := err.(type) {
+	case nil:
+	case MultiErr:
+		h.errs = append(h.errs, err...)
+	default:
+		if h.abortErr == nil {
+			h.abortErr = err
+		}
+	}
+}
+
+// aborted returns true if an error stopped reading.
+func (h *errorHandler) aborted() bool {
+	return h.abortErr != nil
+}
+
+// err returns the error stopping reading, if any,
+// or the errors collected as a MultiErr.
+func (h *errorHandler) err() error {
+	if h.abortErr != nil {
+		return h.abortErr
+	}
+	if len(h.errs) > 0 {
+		return h.errs
+	}
+	return nil
+}
+
+// markVisited marks the directory with the given identifier as visited
+// and returns true if it was not visited before.
+func (r *dirReader) markVisited(id fileID) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.visited[id] {
+		return false
+	}
+
+	r.visited[id] = true
+	return true
+}
+
+// statFileID returns the identifier of the file with the given filename
+// and information, following symbolic links.
+// If info is nil, the file is read from the filesystem.
+func statFileID(filename string, info os.FileInfo) (fileID, error) {
+	if info == nil {
+		var err error
+		if info, err = os.Stat(filename); err != nil {
+			return fileID{}, err
+		}
+	}
+
+	return getFileID(filename, info)
+}
+
+// realPath returns the path of the given entry with symbolic links resolved.
+// If isWalked is true, the real path is recorded for the entry's children.
+func (r *dirReader) realPath(osPathname string, de *godirwalk.Dirent, isWalked bool) string {
+	r.mu.Lock()
+	realDir := r.realDirs[filepath.Dir(osPathname)]
+	r.mu.Unlock()
+
+	realPath := filepath.Join(realDir, de.Name())
+	if de.IsSymlink() {
+		if evalPath, err := filepath.EvalSymlinks(osPathname); err == nil {
+			realPath = evalPath
+		}
+	}
+
+	if isWalked {
+		r.mu.Lock()
+		r.realDirs[osPathname] = realPath
+		r.mu.Unlock()
+	}
+
+	return realPath
+}
+
+// relPath returns the given pathname relative to the given root directory,
+// or an empty string if they coincide.
+// Both pathnames must be clean and pathname must be inside root.
+func relPath(root, pathname string) string {
+	if pathname == root {
+		return ""
+	}
+	if root == "." {
+		return pathname
+	}
+
+	return strings.TrimPrefix(pathname[len(root):], string(filepath.Separator))
+}
+
+// includeType returns true if files of the given type
+// should be read following the given options.
+func includeType(typ os.FileMode, options *ReadDirOptions) bool {
+	switch {
+	case typ&os.ModeType == 0:
+		return true
+	case typ&os.ModeDir != 0:
+		return options.IncludeDirs
+	case typ&os.ModeSymlink != 0:
+		return options.IncludeSymlinks
+	default:
+		return options.IncludeSpecial
+	}
+}
+
+// matchFile returns true if the given file satisfies
+// the filters specified by the given options.
+func matchFile(fi *FileInfo, options *ReadDirOptions) (bool, error) {
+	if options.MinSize > 0 && fi.IsRegular() && fi.Size < options.MinSize {
+		return false, nil
+	}
+	if options.MaxSize > 0 && fi.IsRegular() && fi.Size > options.MaxSize {
+		return false, nil
+	}
+	if !options.ModifiedAfter.IsZero() && !fi.ModTime.After(options.ModifiedAfter) {
+		return false, nil
+	}
+	if !options.ModifiedBefore.IsZero() && !fi.ModTime.Before(options.ModifiedBefore) {
+		return false, nil
+	}
+	if len(options.ContentTypes) > 0 && fi.IsRegular() {
+		contentType, err := fi.DetectContentType()
+		if err != nil {
+			return false, err
+		}
+		if !matchContentType(contentType, options.ContentTypes) {
+			return false, nil
+		}
+	}
+	if (options.MinImageWidth > 0 || options.MinImageHeight > 0) && fi.IsRegular() {
+		if match, err := matchImageSize(fi, options); err != nil || !match {
+			return false, err
+		}
+	}
+	if options.ReadExif && fi.IsRegular() {
+		_, err := fi.ReadExif()
+		if err != nil && err != NoExifErr && err != InvalidExifErr {
+			return false, err
+		}
+	}
+	if options.Filter != nil && !options.Filter(fi) {
+		return false, nil
+	}
+	return true, nil
 }
 
 // SubdirOf returns true if the given dirname is a subdirectory
@@ -0,0 +1,64 @@
+package fs
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadDir_oneFileSystem(t *testing.T) {
+	assert := assert.New(t)
+
+	// /proc is usually a separate filesystem mounted inside /.
+	rootID, err := statFileID("/", nil)
+	assert.Nil(err)
+	procID, err := statFileID("/proc", nil)
+	if err != nil || procID.dev == rootID.dev {
+		t.Skip("/proc is not a separate filesystem")
+	}
+
+	onlyProc := func(fi *FileInfo) bool {
+		return fi.Path == "/proc" || filepath.Dir(fi.Path) == "/proc"
+	}
+
+	tests := []struct {
+		name          string
+		oneFileSystem bool
+		wantProcFiles bool
+	}{
+		{
+			"cross filesystems",
+			false,
+			true,
+		},
+		{
+			"stay on one filesystem",
+			true,
+			false,
+		},
+	}
+	for _, tt := range tests {
+		got, _ := ReadDir("/", &ReadDirOptions{
+			IncludeSubdirs: true,
+			IncludeDirs:    true,
+			MaxDepth:       2,
+			OneFileSystem:  tt.oneFileSystem,
+			Filter:         onlyProc,
+		})
+
+		gotProcDir := false
+		gotProcFiles := false
+		for _, fi := range got {
+			if fi.Path == "/proc" {
+				gotProcDir = true
+				assert.Equal(os.ModeDir, fi.Type, tt.name)
+			} else {
+				gotProcFiles = true
+			}
+		}
+		assert.True(gotProcDir, tt.name)
+		assert.Equal(tt.wantProcFiles, gotProcFiles, tt.name)
+	}
+}
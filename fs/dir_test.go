@@ -5,6 +5,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -491,68 +492,200 @@ func TestReadDir(t *testing.T) {
 	assert.Nil(err)
 	file3.Close()
 	defer os.RemoveAll(dir2)
+	file3Info, err := os.Stat(file3.Name())
+	assert.Nil(err)
+	file3Contents := &FileInfo{
+		Name:    filepath.Base(file3.Name()),
+		Stem:    filepath.Base(file3.Name()),
+		Dir:     dir3,
+		Path:    file3.Name(),
+		Root:    dir2,
+		RelPath: filepath.Base(dir3) + "/" + filepath.Base(file3.Name()),
+		Depth:   2,
+	}
+	file3Contents.setStat(file3Info)
+
+	dir4, err := ioutil.TempDir("", "dir")
+	assert.Nil(err)
+	defer os.RemoveAll(dir4)
+	modTime := time.Now().Add(-time.Hour)
+	dir4Files := []struct {
+		name    string
+		size    int
+		modTime time.Time
+	}{
+		{"file1.gif", 30, modTime.Add(2 * time.Minute)},
+		{"file10.gif", 20, modTime},
+		{"file2.txt", 10, modTime.Add(time.Minute)},
+	}
+	dir4Contents := make([]*FileInfo, 0, len(dir4Files))
+	for _, f := range dir4Files {
+		filename := filepath.Join(dir4, f.name)
+		assert.Nil(writeTestFile(filename, f.size, f.modTime))
+		fi, err := ReadFileInfo(filename)
+		assert.Nil(err)
+		fi.Depth = 1
+		fi.Root = dir4
+		fi.RelPath = f.name
+		dir4Contents = append(dir4Contents, fi)
+	}
 
 	wd, err := os.Getwd()
 	assert.Nil(err)
 	testdir1 := filepath.Join(filepath.Dir(wd), "testdata", "read_dir_test")
 	testdir1Contents := []*FileInfo{
 		{
-			Name: "10.gif",
-			Ext:  ".gif",
-			Dir:  testdir1,
-			Path: filepath.Join(testdir1, "10.gif"),
-			Size: 799,
-		},
-		{
-			Name: "20.gif",
-			Ext:  ".gif",
-			Dir:  testdir1,
-			Path: filepath.Join(testdir1, "20.gif"),
-			Size: 799,
-		},
-		{
-			Name: "30.gif",
-			Ext:  ".gif",
-			Dir:  filepath.Join(testdir1, "dir1"),
-			Path: filepath.Join(testdir1, "dir1", "30.gif"),
-			Size: 799,
-		},
-		{
-			Name: "40.gif",
-			Ext:  ".gif",
-			Dir:  filepath.Join(testdir1, "dir1"),
-			Path: filepath.Join(testdir1, "dir1", "40.gif"),
-			Size: 799,
-		},
-		{
-			Name: "50.gif",
-			Ext:  ".gif",
-			Dir:  filepath.Join(testdir1, "dir1", "subdir1"),
-			Path: filepath.Join(testdir1, "dir1", "subdir1", "50.gif"),
-			Size: 799,
+			Name:    "10.gif",
+			Stem:    "10",
+			Ext:     ".gif",
+			Dir:     testdir1,
+			Path:    filepath.Join(testdir1, "10.gif"),
+			Root:    testdir1,
+			RelPath: "10.gif",
+			Size:    799,
+			Depth:   1,
+		},
+		{
+			Name:    "20.gif",
+			Stem:    "20",
+			Ext:     ".gif",
+			Dir:     testdir1,
+			Path:    filepath.Join(testdir1, "20.gif"),
+			Root:    testdir1,
+			RelPath: "20.gif",
+			Size:    799,
+			Depth:   1,
+		},
+		{
+			Name:    "30.gif",
+			Stem:    "30",
+			Ext:     ".gif",
+			Dir:     filepath.Join(testdir1, "dir1"),
+			Path:    filepath.Join(testdir1, "dir1", "30.gif"),
+			Root:    testdir1,
+			RelPath: "dir1/30.gif",
+			Size:    799,
+			Depth:   2,
+		},
+		{
+			Name:    "40.gif",
+			Stem:    "40",
+			Ext:     ".gif",
+			Dir:     filepath.Join(testdir1, "dir1"),
+			Path:    filepath.Join(testdir1, "dir1", "40.gif"),
+			Root:    testdir1,
+			RelPath: "dir1/40.gif",
+			Size:    799,
+			Depth:   2,
+		},
+		{
+			Name:    "50.gif",
+			Stem:    "50",
+			Ext:     ".gif",
+			Dir:     filepath.Join(testdir1, "dir1", "subdir1"),
+			Path:    filepath.Join(testdir1, "dir1", "subdir1", "50.gif"),
+			Root:    testdir1,
+			RelPath: "dir1/subdir1/50.gif",
+			Size:    799,
+			Depth:   3,
+		},
+		{
+			Name:    "60.gif",
+			Stem:    "60",
+			Ext:     ".gif",
+			Dir:     filepath.Join(testdir1, "dir1", "subdir1"),
+			Path:    filepath.Join(testdir1, "dir1", "subdir1", "60.gif"),
+			Root:    testdir1,
+			RelPath: "dir1/subdir1/60.gif",
+			Size:    799,
+			Depth:   3,
+		},
+		{
+			Name:    "70.gif",
+			Stem:    "70",
+			Ext:     ".gif",
+			Dir:     filepath.Join(testdir1, "dir2"),
+			Path:    filepath.Join(testdir1, "dir2", "70.gif"),
+			Root:    testdir1,
+			RelPath: "dir2/70.gif",
+			Size:    799,
+			Depth:   2,
+		},
+		{
+			Name:    "80.gif",
+			Stem:    "80",
+			Ext:     ".gif",
+			Dir:     filepath.Join(testdir1, "dir2"),
+			Path:    filepath.Join(testdir1, "dir2", "80.gif"),
+			Root:    testdir1,
+			RelPath: "dir2/80.gif",
+			Size:    799,
+			Depth:   2,
 		},
-		{
-			Name: "60.gif",
-			Ext:  ".gif",
-			Dir:  filepath.Join(testdir1, "dir1", "subdir1"),
-			Path: filepath.Join(testdir1, "dir1", "subdir1", "60.gif"),
-			Size: 799,
-		},
-		{
-			Name: "70.gif",
-			Ext:  ".gif",
-			Dir:  filepath.Join(testdir1, "dir2"),
-			Path: filepath.Join(testdir1, "dir2", "70.gif"),
-			Size: 799,
-		},
-		{
-			Name: "80.gif",
-			Ext:  ".gif",
-			Dir:  filepath.Join(testdir1, "dir2"),
-			Path: filepath.Join(testdir1, "dir2", "80.gif"),
-			Size: 799,
+	}
+	for _, fi := range testdir1Contents {
+		info, err := os.Stat(fi.Path)
+		assert.Nil(err)
+		fi.setStat(info)
+	}
+	testdir1Dirs := []*FileInfo{
+		{
+			Name:    "dir1",
+			Stem:    "dir1",
+			Dir:     testdir1,
+			Path:    filepath.Join(testdir1, "dir1"),
+			Root:    testdir1,
+			RelPath: "dir1",
+			Type:    os.ModeDir,
+			Depth:   1,
+		},
+		{
+			Name:    "subdir1",
+			Stem:    "subdir1",
+			Dir:     filepath.Join(testdir1, "dir1"),
+			Path:    filepath.Join(testdir1, "dir1", "subdir1"),
+			Root:    testdir1,
+			RelPath: "dir1/subdir1",
+			Type:    os.ModeDir,
+			Depth:   2,
+		},
+		{
+			Name:    "dir2",
+			Stem:    "dir2",
+			Dir:     testdir1,
+			Path:    filepath.Join(testdir1, "dir2"),
+			Root:    testdir1,
+			RelPath: "dir2",
+			Type:    os.ModeDir,
+			Depth:   1,
 		},
 	}
+	for _, fi := range testdir1Dirs {
+		info, err := os.Stat(fi.Path)
+		assert.Nil(err)
+		fi.setStat(info)
+	}
+	testdir1Unstated := make([]*FileInfo, 0, len(testdir1Contents))
+	for _, fi := range testdir1Contents {
+		unstated := *newPathInfo(fi.Path, fi.Type)
+		unstated.Root = fi.Root
+		unstated.RelPath = fi.RelPath
+		unstated.Depth = fi.Depth
+		testdir1Unstated = append(testdir1Unstated, &unstated)
+	}
+	testdir1All := []*FileInfo{
+		testdir1Contents[0],
+		testdir1Contents[1],
+		testdir1Dirs[0],
+		testdir1Contents[2],
+		testdir1Contents[3],
+		testdir1Dirs[1],
+		testdir1Contents[4],
+		testdir1Contents[5],
+		testdir1Dirs[2],
+		testdir1Contents[6],
+		testdir1Contents[7],
+	}
 
 	type args struct {
 		dirname string
@@ -645,13 +778,7 @@ func TestReadDir(t *testing.T) {
 				},
 			},
 			[]*FileInfo{
-				{
-					Name: filepath.Base(file3.Name()),
-					Ext:  filepath.Ext(file3.Name()),
-					Dir:  dir3,
-					Path: file3.Name(),
-					Size: 0,
-				},
+				file3Contents,
 			},
 			false,
 		},
@@ -677,13 +804,7 @@ func TestReadDir(t *testing.T) {
 				},
 			},
 			[]*FileInfo{
-				{
-					Name: filepath.Base(file3.Name()),
-					Ext:  filepath.Ext(file3.Name()),
-					Dir:  dir3,
-					Path: file3.Name(),
-					Size: 0,
-				},
+				file3Contents,
 			},
 			false,
 		},
@@ -709,13 +830,7 @@ func TestReadDir(t *testing.T) {
 				},
 			},
 			[]*FileInfo{
-				{
-					Name: filepath.Base(file3.Name()),
-					Ext:  filepath.Ext(file3.Name()),
-					Dir:  dir3,
-					Path: file3.Name(),
-					Size: 0,
-				},
+				file3Contents,
 			},
 			false,
 		},
@@ -885,10 +1000,805 @@ func TestReadDir(t *testing.T) {
 			testdir1Contents,
 			false,
 		},
+		{
+			"non-empty dir, include subdirs, min size 799 bytes",
+			args{
+				testdir1,
+				&ReadDirOptions{
+					IncludeSubdirs: true,
+					MinSize:        799,
+				},
+			},
+			testdir1Contents,
+			false,
+		},
+		{
+			"non-empty dir, include subdirs, min size 800 bytes",
+			args{
+				testdir1,
+				&ReadDirOptions{
+					IncludeSubdirs: true,
+					MinSize:        800,
+				},
+			},
+			[]*FileInfo{},
+			false,
+		},
+		{
+			"non-empty dir, include subdirs, max size 799 bytes",
+			args{
+				testdir1,
+				&ReadDirOptions{
+					IncludeSubdirs: true,
+					MaxSize:        799,
+				},
+			},
+			testdir1Contents,
+			false,
+		},
+		{
+			"non-empty dir, include subdirs, max size 798 bytes",
+			args{
+				testdir1,
+				&ReadDirOptions{
+					IncludeSubdirs: true,
+					MaxSize:        798,
+				},
+			},
+			[]*FileInfo{},
+			false,
+		},
+		{
+			"non-empty dir, include subdirs, modified after the future",
+			args{
+				testdir1,
+				&ReadDirOptions{
+					IncludeSubdirs: true,
+					ModifiedAfter:  time.Now().Add(time.Hour),
+				},
+			},
+			[]*FileInfo{},
+			false,
+		},
+		{
+			"non-empty dir, include subdirs, modified before the future",
+			args{
+				testdir1,
+				&ReadDirOptions{
+					IncludeSubdirs: true,
+					ModifiedBefore: time.Now().Add(time.Hour),
+				},
+			},
+			testdir1Contents,
+			false,
+		},
+		{
+			"non-empty dir, include subdirs, filter",
+			args{
+				testdir1,
+				&ReadDirOptions{
+					IncludeSubdirs: true,
+					Filter: func(fi *FileInfo) bool {
+						return fi.Name >= "50"
+					},
+				},
+			},
+			testdir1Contents[4:],
+			false,
+		},
+		{
+			"non-empty dir, include subdirs, filter, limit 2 files",
+			args{
+				testdir1,
+				&ReadDirOptions{
+					IncludeSubdirs: true,
+					MaxFiles:       2,
+					Filter: func(fi *FileInfo) bool {
+						return fi.Name >= "50"
+					},
+				},
+			},
+			testdir1Contents[4:6],
+			false,
+		},
+		{
+			"non-empty dir, exclude subdirs, max depth 3",
+			args{
+				testdir1,
+				&ReadDirOptions{
+					IncludeSubdirs: false,
+					MaxDepth:       3,
+				},
+			},
+			testdir1Contents[:2],
+			false,
+		},
+		{
+			"non-empty dir, include subdirs, max depth 1",
+			args{
+				testdir1,
+				&ReadDirOptions{
+					IncludeSubdirs: true,
+					MaxDepth:       1,
+				},
+			},
+			testdir1Contents[:2],
+			false,
+		},
+		{
+			"non-empty dir, include subdirs, max depth 2",
+			args{
+				testdir1,
+				&ReadDirOptions{
+					IncludeSubdirs: true,
+					MaxDepth:       2,
+				},
+			},
+			append(append([]*FileInfo{}, testdir1Contents[:4]...), testdir1Contents[6:]...),
+			false,
+		},
+		{
+			"non-empty dir, include subdirs, min depth 2, max depth 2",
+			args{
+				testdir1,
+				&ReadDirOptions{
+					IncludeSubdirs: true,
+					MinDepth:       2,
+					MaxDepth:       2,
+				},
+			},
+			append(append([]*FileInfo{}, testdir1Contents[2:4]...), testdir1Contents[6:]...),
+			false,
+		},
+		{
+			"non-empty dir, include subdirs, min depth 3",
+			args{
+				testdir1,
+				&ReadDirOptions{
+					IncludeSubdirs: true,
+					MinDepth:       3,
+				},
+			},
+			testdir1Contents[4:6],
+			false,
+		},
+		{
+			"non-empty dir, include subdirs, min depth 4",
+			args{
+				testdir1,
+				&ReadDirOptions{
+					IncludeSubdirs: true,
+					MinDepth:       4,
+				},
+			},
+			[]*FileInfo{},
+			false,
+		},
+		{
+			"non-empty dir, exclude subdirs, include dirs",
+			args{
+				testdir1,
+				&ReadDirOptions{
+					IncludeSubdirs: false,
+					IncludeDirs:    true,
+				},
+			},
+			[]*FileInfo{testdir1All[0], testdir1All[1], testdir1All[2], testdir1All[8]},
+			false,
+		},
+		{
+			"non-empty dir, include subdirs, include dirs",
+			args{
+				testdir1,
+				&ReadDirOptions{
+					IncludeSubdirs: true,
+					IncludeDirs:    true,
+				},
+			},
+			testdir1All,
+			false,
+		},
+		{
+			"non-empty dir, include subdirs, include dirs, limit 3 files",
+			args{
+				testdir1,
+				&ReadDirOptions{
+					IncludeSubdirs: true,
+					IncludeDirs:    true,
+					MaxFiles:       3,
+				},
+			},
+			testdir1All[:3],
+			false,
+		},
+		{
+			"non-empty dir, include subdirs, include dirs, max depth 1",
+			args{
+				testdir1,
+				&ReadDirOptions{
+					IncludeSubdirs: true,
+					IncludeDirs:    true,
+					MaxDepth:       1,
+				},
+			},
+			[]*FileInfo{testdir1All[0], testdir1All[1], testdir1All[2], testdir1All[8]},
+			false,
+		},
+		{
+			"non-empty dir, include subdirs, include dirs, min size 799 bytes",
+			args{
+				testdir1,
+				&ReadDirOptions{
+					IncludeSubdirs: true,
+					IncludeDirs:    true,
+					MinSize:        799,
+					MaxSize:        799,
+				},
+			},
+			testdir1All,
+			false,
+		},
+		{
+			"non-empty dir, include subdirs, include dirs, dirs first",
+			args{
+				testdir1,
+				&ReadDirOptions{
+					IncludeSubdirs: true,
+					IncludeDirs:    true,
+					DirsFirst:      true,
+				},
+			},
+			append(append([]*FileInfo{}, testdir1Dirs...), testdir1Contents...),
+			false,
+		},
+		{
+			"non-empty dir, include subdirs, include dirs, dirs first, descending",
+			args{
+				testdir1,
+				&ReadDirOptions{
+					IncludeSubdirs: true,
+					IncludeDirs:    true,
+					DirsFirst:      true,
+					SortDescending: true,
+				},
+			},
+			[]*FileInfo{
+				testdir1Dirs[2],
+				testdir1Dirs[1],
+				testdir1Dirs[0],
+				testdir1Contents[7],
+				testdir1Contents[6],
+				testdir1Contents[5],
+				testdir1Contents[4],
+				testdir1Contents[3],
+				testdir1Contents[2],
+				testdir1Contents[1],
+				testdir1Contents[0],
+			},
+			false,
+		},
+		{
+			"non-empty dir, include subdirs, 4 workers",
+			args{
+				testdir1,
+				&ReadDirOptions{
+					IncludeSubdirs: true,
+					Workers:        4,
+				},
+			},
+			testdir1Contents,
+			false,
+		},
+		{
+			"non-empty dir, include subdirs, 4 workers, limit 3 files",
+			args{
+				testdir1,
+				&ReadDirOptions{
+					IncludeSubdirs: true,
+					Workers:        4,
+					MaxFiles:       3,
+				},
+			},
+			testdir1Contents[:3],
+			false,
+		},
+		{
+			"non-empty dir, include subdirs, include dirs, 4 workers",
+			args{
+				testdir1,
+				&ReadDirOptions{
+					IncludeSubdirs: true,
+					IncludeDirs:    true,
+					Workers:        4,
+				},
+			},
+			testdir1All,
+			false,
+		},
+		{
+			"non-empty dir, include subdirs, skip stat",
+			args{
+				testdir1,
+				&ReadDirOptions{
+					IncludeSubdirs: true,
+					SkipStat:       true,
+				},
+			},
+			testdir1Unstated,
+			false,
+		},
+		{
+			"non-empty dir, include subdirs, skip stat, 4 workers",
+			args{
+				testdir1,
+				&ReadDirOptions{
+					IncludeSubdirs: true,
+					SkipStat:       true,
+					Workers:        4,
+				},
+			},
+			testdir1Unstated,
+			false,
+		},
+		{
+			"non-empty dir, include subdirs, skip stat, min size 799 bytes",
+			args{
+				testdir1,
+				&ReadDirOptions{
+					IncludeSubdirs: true,
+					SkipStat:       true,
+					MinSize:        799,
+				},
+			},
+			testdir1Contents,
+			false,
+		},
+		{
+			"non-empty dir, include subdirs, skip stat, sort by modification time",
+			args{
+				testdir1,
+				&ReadDirOptions{
+					IncludeSubdirs: true,
+					SkipStat:       true,
+					Sort:           SortByModTime,
+				},
+			},
+			testdir1Contents,
+			false,
+		},
+		{
+			"non-empty dir, include subdirs, one filesystem",
+			args{
+				testdir1,
+				&ReadDirOptions{
+					IncludeSubdirs: true,
+					IncludeDirs:    true,
+					OneFileSystem:  true,
+				},
+			},
+			testdir1All,
+			false,
+		},
+		{
+			"sort by path",
+			args{
+				dir4,
+				&ReadDirOptions{
+					Sort: SortByPath,
+				},
+			},
+			[]*FileInfo{dir4Contents[0], dir4Contents[1], dir4Contents[2]},
+			false,
+		},
+		{
+			"sort by path, descending",
+			args{
+				dir4,
+				&ReadDirOptions{
+					Sort:           SortByPath,
+					SortDescending: true,
+				},
+			},
+			[]*FileInfo{dir4Contents[2], dir4Contents[1], dir4Contents[0]},
+			false,
+		},
+		{
+			"sort by path, descending, limit 1 file",
+			args{
+				dir4,
+				&ReadDirOptions{
+					MaxFiles:       1,
+					Sort:           SortByPath,
+					SortDescending: true,
+				},
+			},
+			[]*FileInfo{dir4Contents[2]},
+			false,
+		},
+		{
+			"sort by natural path",
+			args{
+				dir4,
+				&ReadDirOptions{
+					Sort: SortByNaturalPath,
+				},
+			},
+			[]*FileInfo{dir4Contents[0], dir4Contents[2], dir4Contents[1]},
+			false,
+		},
+		{
+			"sort by size",
+			args{
+				dir4,
+				&ReadDirOptions{
+					Sort: SortBySize,
+				},
+			},
+			[]*FileInfo{dir4Contents[2], dir4Contents[1], dir4Contents[0]},
+			false,
+		},
+		{
+			"sort by size, 2 workers",
+			args{
+				dir4,
+				&ReadDirOptions{
+					Sort:    SortBySize,
+					Workers: 2,
+				},
+			},
+			[]*FileInfo{dir4Contents[2], dir4Contents[1], dir4Contents[0]},
+			false,
+		},
+		{
+			"sort by size, limit 2 files",
+			args{
+				dir4,
+				&ReadDirOptions{
+					MaxFiles: 2,
+					Sort:     SortBySize,
+				},
+			},
+			[]*FileInfo{dir4Contents[2], dir4Contents[1]},
+			false,
+		},
+		{
+			"sort by modification time",
+			args{
+				dir4,
+				&ReadDirOptions{
+					Sort: SortByModTime,
+				},
+			},
+			[]*FileInfo{dir4Contents[1], dir4Contents[2], dir4Contents[0]},
+			false,
+		},
+		{
+			"sort by modification time, descending",
+			args{
+				dir4,
+				&ReadDirOptions{
+					Sort:           SortByModTime,
+					SortDescending: true,
+				},
+			},
+			[]*FileInfo{dir4Contents[0], dir4Contents[2], dir4Contents[1]},
+			false,
+		},
+		{
+			"sort by extension",
+			args{
+				dir4,
+				&ReadDirOptions{
+					Sort: SortByExt,
+				},
+			},
+			[]*FileInfo{dir4Contents[0], dir4Contents[1], dir4Contents[2]},
+			false,
+		},
+		{
+			"sort by extension, descending",
+			args{
+				dir4,
+				&ReadDirOptions{
+					Sort:           SortByExt,
+					SortDescending: true,
+				},
+			},
+			[]*FileInfo{dir4Contents[2], dir4Contents[1], dir4Contents[0]},
+			false,
+		},
 	}
 	for _, tt := range tests {
 		got, gotErr := ReadDir(tt.args.dirname, tt.args.options)
 		assert.Equal(tt.wantErr, gotErr != nil, tt.name)
+		assert.Equal(withoutAccessTimes(tt.want), withoutAccessTimes(got), tt.name)
+	}
+}
+
+// withoutAccessTimes returns copies of the given files without access times,
+// which change when directories are read.
+func withoutAccessTimes(fileInfos []*FileInfo) []*FileInfo {
+	if fileInfos == nil {
+		return nil
+	}
+
+	copies := make([]*FileInfo, 0, len(fileInfos))
+	for _, fi := range fileInfos {
+		c := *fi
+		c.AccessTime = time.Time{}
+		copies = append(copies, &c)
+	}
+	return copies
+}
+
+func TestReadDir_errors(t *testing.T) {
+	assert := assert.New(t)
+
+	// Removing a directory and a file while reading the test directory
+	// causes errors on them, regardless of the user's permissions.
+	removeFilter := func(fi *FileInfo) bool {
+		if fi.Name == "a.txt" {
+			_ = os.RemoveAll(filepath.Join(fi.Dir, "b"))
+			_ = os.Remove(filepath.Join(fi.Dir, "c.txt"))
+		}
+		return true
+	}
+
+	type args struct {
+		errorPolicy   ErrorPolicy
+		errorCallback func(string, error) ErrorPolicy
+	}
+	tests := []struct {
+		name         string
+		args         args
+		wantNames    []string
+		wantErrPaths []string
+		wantAbort    bool
+	}{
+		{
+			"ignore errors",
+			args{
+				IgnoreErrors,
+				nil,
+			},
+			[]string{"a.txt"},
+			nil,
+			false,
+		},
+		{
+			"collect errors",
+			args{
+				CollectErrors,
+				nil,
+			},
+			[]string{"a.txt"},
+			[]string{"b", "c.txt"},
+			false,
+		},
+		{
+			"abort on error",
+			args{
+				AbortOnError,
+				nil,
+			},
+			[]string{"a.txt"},
+			[]string{"b"},
+			true,
+		},
+		{
+			"error callback",
+			args{
+				AbortOnError,
+				func(pathname string, _ error) ErrorPolicy {
+					if filepath.Base(pathname) == "c.txt" {
+						return CollectErrors
+					}
+					return IgnoreErrors
+				},
+			},
+			[]string{"a.txt"},
+			[]string{"c.txt"},
+			false,
+		},
+	}
+	for _, tt := range tests {
+		dir1, err := ioutil.TempDir("", "dir")
+		assert.Nil(err)
+		assert.Nil(os.Mkdir(filepath.Join(dir1, "b"), 0755))
+		for _, name := range []string{"a.txt", filepath.Join("b", "d.txt"), "c.txt"} {
+			assert.Nil(ioutil.WriteFile(filepath.Join(dir1, name), nil, defaultFilePermissions))
+		}
+
+		got, gotErr := ReadDir(dir1, &ReadDirOptions{
+			IncludeSubdirs: true,
+			Filter:         removeFilter,
+			ErrorPolicy:    tt.args.errorPolicy,
+			ErrorCallback:  tt.args.errorCallback,
+		})
+		_ = os.RemoveAll(dir1)
+
+		gotNames := make([]string, 0, len(got))
+		for _, fi := range got {
+			gotNames = append(gotNames, fi.Name)
+		}
+		assert.Equal(tt.wantNames, gotNames, tt.name)
+
+		var gotErrPaths []string
+		switch err := gotErr.(type) {
+		case MultiErr:
+			assert.False(tt.wantAbort, tt.name)
+			for _, pathErr := range err {
+				gotErrPaths = append(gotErrPaths, filepath.Base(pathErr.Path))
+			}
+		case *PathErr:
+			assert.True(tt.wantAbort, tt.name)
+			gotErrPaths = append(gotErrPaths, filepath.Base(err.Path))
+		default:
+			assert.Nil(gotErr, tt.name)
+		}
+		assert.Equal(tt.wantErrPaths, gotErrPaths, tt.name)
+	}
+}
+
+func TestReadDir_rootErrors(t *testing.T) {
+	assert := assert.New(t)
+
+	dir1, err := ioutil.TempDir("", "dir")
+	assert.Nil(err)
+	defer os.RemoveAll(dir1)
+
+	// A root removed after being checked is an error for both walks.
+	missing := filepath.Join(dir1, "missing")
+	for _, workers := range []int{0, 4} {
+		got, err := readDir(missing, &ReadDirOptions{ErrorPolicy: AbortOnError, Workers: workers})
+		assert.Empty(got, "workers %d", workers)
+		if assert.IsType(&PathErr{}, err, "workers %d", workers) {
+			assert.Equal(missing, err.(*PathErr).Path, "workers %d", workers)
+		}
+	}
+}
+
+func Test_pathOrderOptions(t *testing.T) {
+	assert := assert.New(t)
+
+	options := &ReadDirOptions{
+		IncludeSubdirs: true,
+		Sort:           SortBySize,
+		SortDescending: true,
+		DirsFirst:      true,
+		MaxFiles:       10,
+		Hash:           SHA256,
+	}
+	got := pathOrderOptions(options)
+	assert.Equal(&ReadDirOptions{IncludeSubdirs: true, Sort: SortByPath}, got)
+	assert.Equal(SHA256, options.Hash)
+}
+
+func Test_relPath(t *testing.T) {
+	assert := assert.New(t)
+
+	sep := string(filepath.Separator)
+
+	type args struct {
+		root     string
+		pathname string
+	}
+	tests := []struct {
+		name string
+		args args
+		want string
+	}{
+		{
+			"root",
+			args{
+				"dir",
+				"dir",
+			},
+			"",
+		},
+		{
+			"direct child",
+			args{
+				"dir",
+				"dir" + sep + "file",
+			},
+			"file",
+		},
+		{
+			"nested child",
+			args{
+				"dir",
+				"dir" + sep + "subdir" + sep + "file",
+			},
+			"subdir" + sep + "file",
+		},
+		{
+			"nested child of filesystem root",
+			args{
+				sep,
+				sep + "subdir" + sep + "file",
+			},
+			"subdir" + sep + "file",
+		},
+		{
+			"nested child of current directory",
+			args{
+				".",
+				"subdir" + sep + "file",
+			},
+			"subdir" + sep + "file",
+		},
+	}
+	for _, tt := range tests {
+		got := relPath(tt.args.root, tt.args.pathname)
 		assert.Equal(tt.want, got, tt.name)
 	}
 }
+
+func TestReadDir_hidden(t *testing.T) {
+	assert := assert.New(t)
+
+	dir1, err := ioutil.TempDir("", "dir")
+	assert.Nil(err)
+	defer os.RemoveAll(dir1)
+	assert.Nil(os.Mkdir(filepath.Join(dir1, ".hdir"), 0755))
+	assert.Nil(os.Mkdir(filepath.Join(dir1, "vdir"), 0755))
+	for _, name := range []string{
+		filepath.Join(".hdir", "a.txt"),
+		".hidden.txt",
+		filepath.Join("vdir", ".b.txt"),
+		"visible.txt",
+	} {
+		assert.Nil(ioutil.WriteFile(filepath.Join(dir1, name), nil, defaultFilePermissions))
+	}
+
+	tests := []struct {
+		name       string
+		hidden     HiddenPolicy
+		wantNames  []string
+		wantHidden []bool
+	}{
+		{
+			"include hidden",
+			IncludeHidden,
+			[]string{".hdir", "a.txt", ".hidden.txt", "vdir", ".b.txt", "visible.txt"},
+			[]bool{true, false, true, false, true, false},
+		},
+		{
+			"exclude hidden files",
+			ExcludeHiddenFiles,
+			[]string{".hdir", "a.txt", "vdir", "visible.txt"},
+			[]bool{true, false, false, false},
+		},
+		{
+			"exclude hidden",
+			ExcludeHidden,
+			[]string{"vdir", "visible.txt"},
+			[]bool{false, false},
+		},
+	}
+	for _, tt := range tests {
+		got, gotErr := ReadDir(dir1, &ReadDirOptions{
+			IncludeSubdirs: true,
+			IncludeDirs:    true,
+			Hidden:         tt.hidden,
+		})
+		assert.Nil(gotErr, tt.name)
+
+		gotNames := make([]string, 0, len(got))
+		gotHidden := make([]bool, 0, len(got))
+		for _, fi := range got {
+			gotNames = append(gotNames, fi.Name)
+			gotHidden = append(gotHidden, fi.Hidden)
+		}
+		assert.Equal(tt.wantNames, gotNames, tt.name)
+		assert.Equal(tt.wantHidden, gotHidden, tt.name)
+	}
+}
+
+func writeTestFile(filename string, size int, modTime time.Time) error {
+	if err := ioutil.WriteFile(filename, make([]byte, size), defaultFilePermissions); err != nil {
+		return err
+	}
+	return os.Chtimes(filename, modTime, modTime)
+}
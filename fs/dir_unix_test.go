@@ -0,0 +1,275 @@
+//go:build !windows
+// +build !windows
+
+package fs
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadDir_types(t *testing.T) {
+	assert := assert.New(t)
+
+	dir1, err := ioutil.TempDir("", "dir")
+	assert.Nil(err)
+	defer os.RemoveAll(dir1)
+
+	file1 := filepath.Join(dir1, "file1")
+	assert.Nil(ioutil.WriteFile(file1, nil, defaultFilePermissions))
+	link1 := filepath.Join(dir1, "link1")
+	assert.Nil(os.Symlink(file1, link1))
+	pipe1 := filepath.Join(dir1, "pipe1")
+	assert.Nil(syscall.Mkfifo(pipe1, defaultFilePermissions))
+
+	type args struct {
+		options *ReadDirOptions
+	}
+	tests := []struct {
+		name      string
+		args      args
+		wantPaths []string
+		wantTypes []os.FileMode
+	}{
+		{
+			"regular files only",
+			args{
+				&ReadDirOptions{},
+			},
+			[]string{file1},
+			[]os.FileMode{0},
+		},
+		{
+			"include symlinks",
+			args{
+				&ReadDirOptions{
+					IncludeSymlinks: true,
+				},
+			},
+			[]string{file1, link1},
+			[]os.FileMode{0, os.ModeSymlink},
+		},
+		{
+			"include special files",
+			args{
+				&ReadDirOptions{
+					IncludeSpecial: true,
+				},
+			},
+			[]string{file1, pipe1},
+			[]os.FileMode{0, os.ModeNamedPipe},
+		},
+		{
+			"include all",
+			args{
+				&ReadDirOptions{
+					IncludeSymlinks: true,
+					IncludeSpecial:  true,
+				},
+			},
+			[]string{file1, link1, pipe1},
+			[]os.FileMode{0, os.ModeSymlink, os.ModeNamedPipe},
+		},
+	}
+	for _, tt := range tests {
+		got, gotErr := ReadDir(dir1, tt.args.options)
+		assert.Nil(gotErr, tt.name)
+
+		gotPaths := make([]string, 0, len(got))
+		gotTypes := make([]os.FileMode, 0, len(got))
+		for _, fi := range got {
+			gotPaths = append(gotPaths, fi.Path)
+			gotTypes = append(gotTypes, fi.Type)
+		}
+		assert.Equal(tt.wantPaths, gotPaths, tt.name)
+		assert.Equal(tt.wantTypes, gotTypes, tt.name)
+	}
+}
+
+func TestReadDir_followSymlinks(t *testing.T) {
+	assert := assert.New(t)
+
+	dir1, err := ioutil.TempDir("", "dir")
+	assert.Nil(err)
+	defer os.RemoveAll(dir1)
+	realDir1, err := filepath.EvalSymlinks(dir1)
+	assert.Nil(err)
+
+	// dir1
+	// ├── a -> b
+	// ├── b
+	// │   ├── file1
+	// │   └── loop -> dir1
+	// ├── c -> b/file1
+	// └── d -> missing
+	assert.Nil(os.Mkdir(filepath.Join(dir1, "b"), 0755))
+	assert.Nil(ioutil.WriteFile(filepath.Join(dir1, "b", "file1"), nil, defaultFilePermissions))
+	assert.Nil(os.Symlink(dir1, filepath.Join(dir1, "b", "loop")))
+	assert.Nil(os.Symlink(filepath.Join(dir1, "b"), filepath.Join(dir1, "a")))
+	assert.Nil(os.Symlink(filepath.Join(dir1, "b", "file1"), filepath.Join(dir1, "c")))
+	assert.Nil(os.Symlink(filepath.Join(dir1, "missing"), filepath.Join(dir1, "d")))
+
+	type args struct {
+		options *ReadDirOptions
+	}
+	tests := []struct {
+		name          string
+		args          args
+		wantPaths     []string
+		wantTypes     []os.FileMode
+		wantRealPaths []string
+	}{
+		{
+			"do not follow symlinks",
+			args{
+				&ReadDirOptions{
+					IncludeSubdirs:  true,
+					IncludeDirs:     true,
+					IncludeSymlinks: true,
+				},
+			},
+			[]string{
+				filepath.Join(dir1, "a"),
+				filepath.Join(dir1, "b"),
+				filepath.Join(dir1, "b", "file1"),
+				filepath.Join(dir1, "b", "loop"),
+				filepath.Join(dir1, "c"),
+				filepath.Join(dir1, "d"),
+			},
+			[]os.FileMode{
+				os.ModeSymlink,
+				os.ModeDir,
+				0,
+				os.ModeSymlink,
+				os.ModeSymlink,
+				os.ModeSymlink,
+			},
+			[]string{"", "", "", "", "", ""},
+		},
+		{
+			"follow symlinks",
+			args{
+				&ReadDirOptions{
+					IncludeSubdirs:  true,
+					IncludeDirs:     true,
+					IncludeSymlinks: true,
+					FollowSymlinks:  true,
+				},
+			},
+			[]string{
+				filepath.Join(dir1, "a"),
+				filepath.Join(dir1, "a", "file1"),
+				filepath.Join(dir1, "a", "loop"),
+				filepath.Join(dir1, "b"),
+				filepath.Join(dir1, "c"),
+				filepath.Join(dir1, "d"),
+			},
+			[]os.FileMode{
+				os.ModeDir,
+				0,
+				os.ModeDir,
+				os.ModeDir,
+				0,
+				os.ModeSymlink,
+			},
+			[]string{
+				filepath.Join(realDir1, "b"),
+				filepath.Join(realDir1, "b", "file1"),
+				realDir1,
+				filepath.Join(realDir1, "b"),
+				filepath.Join(realDir1, "b", "file1"),
+				filepath.Join(realDir1, "d"),
+			},
+		},
+		{
+			"follow symlinks, regular files only",
+			args{
+				&ReadDirOptions{
+					IncludeSubdirs: true,
+					FollowSymlinks: true,
+				},
+			},
+			[]string{
+				filepath.Join(dir1, "a", "file1"),
+				filepath.Join(dir1, "c"),
+			},
+			[]os.FileMode{0, 0},
+			[]string{
+				filepath.Join(realDir1, "b", "file1"),
+				filepath.Join(realDir1, "b", "file1"),
+			},
+		},
+	}
+	for _, tt := range tests {
+		got, gotErr := ReadDir(dir1, tt.args.options)
+		assert.Nil(gotErr, tt.name)
+
+		gotPaths := make([]string, 0, len(got))
+		gotTypes := make([]os.FileMode, 0, len(got))
+		gotRealPaths := make([]string, 0, len(got))
+		for _, fi := range got {
+			gotPaths = append(gotPaths, fi.Path)
+			gotTypes = append(gotTypes, fi.Type)
+			gotRealPaths = append(gotRealPaths, fi.RealPath)
+		}
+		assert.Equal(tt.wantPaths, gotPaths, tt.name)
+		assert.Equal(tt.wantTypes, gotTypes, tt.name)
+		assert.Equal(tt.wantRealPaths, gotRealPaths, tt.name)
+	}
+}
+
+func TestReadDir_brokenLink(t *testing.T) {
+	assert := assert.New(t)
+
+	dir1, err := ioutil.TempDir("", "dir")
+	assert.Nil(err)
+	defer os.RemoveAll(dir1)
+
+	assert.Nil(os.Mkdir(filepath.Join(dir1, "a"), 0755))
+	assert.Nil(ioutil.WriteFile(filepath.Join(dir1, "a", "file1"), nil, defaultFilePermissions))
+	assert.Nil(os.Symlink(filepath.Join(dir1, "missing"), filepath.Join(dir1, "b")))
+
+	for _, workers := range []int{0, 4} {
+		got, err := ReadDir(dir1, &ReadDirOptions{
+			IncludeSubdirs:  true,
+			IncludeSymlinks: true,
+			FollowSymlinks:  true,
+			ErrorPolicy:     AbortOnError,
+			Workers:         workers,
+		})
+		assert.Nil(err, "workers %d", workers)
+		assert.Equal([]string{"a/file1", "b"}, relPaths(got), "workers %d", workers)
+	}
+}
+
+func TestReadDir_symlinkRoot(t *testing.T) {
+	assert := assert.New(t)
+
+	dir1, err := ioutil.TempDir("", "dir")
+	assert.Nil(err)
+	defer os.RemoveAll(dir1)
+
+	realDir1 := filepath.Join(dir1, "real")
+	assert.Nil(os.MkdirAll(filepath.Join(realDir1, "a"), 0755))
+	assert.Nil(ioutil.WriteFile(filepath.Join(realDir1, "a", "file1"), nil, defaultFilePermissions))
+	link1 := filepath.Join(dir1, "link")
+	assert.Nil(os.Symlink(realDir1, link1))
+
+	for _, workers := range []int{0, 4} {
+		got, err := ReadDir(link1, &ReadDirOptions{
+			IncludeSubdirs: true,
+			ErrorPolicy:    AbortOnError,
+			Workers:        workers,
+		})
+		assert.Nil(err, "workers %d", workers)
+		assert.Len(got, 1, "workers %d", workers)
+		for _, fi := range got {
+			assert.Equal(filepath.Join(link1, "a", "file1"), fi.Path, "workers %d", workers)
+		}
+	}
+}
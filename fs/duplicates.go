@@ -0,0 +1,238 @@
+package fs
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+)
+
+// duplicatesBlockSize is the size of the blocks at the start and at the end
+// of files that are compared before comparing whole files.
+const duplicatesBlockSize = 4096
+
+// FindDuplicatesOptions represents the options available for finding duplicate files.
+type FindDuplicatesOptions struct {
+	// ReadDirOptions specifies the files searched in each directory.
+	// Sort, MaxFiles, SkipStat and Hash options are ignored
+	// and only non-empty regular files are compared.
+	ReadDirOptions *ReadDirOptions
+
+	// Workers is the number of files hashed concurrently.
+	// If Workers is not positive, the number of CPUs is used.
+	Workers int
+}
+
+// FindDuplicates reads the directories named by the given dirnames
+// following the given options and returns the groups of files
+// with identical contents, in the order in which they are read.
+// Files are compared by size, then by hash of their first and last blocks
+// and finally by hash of their whole contents.
+// Hard links to the same file, found at different paths or in overlapping
+// directories, are reported once, at the first path found.
+// Errors are handled as specified by the ReadDirOptions and are returned
+// together with the groups of duplicates found.
+func FindDuplicates(dirnames []string, options *FindDuplicatesOptions) ([][]*FileInfo, error) {
+	if options == nil || options.ReadDirOptions == nil {
+		return nil, NoReadDirOptionsErr
+	}
+
+	readOptions := pathOrderOptions(options.ReadDirOptions)
+	readOptions.SkipStat = false
+
+	handler := errorHandler{options: readOptions}
+	var fileInfos []*FileInfo
+	for _, dirname := range dirnames {
+		dirFileInfos, err := ReadDir(dirname, readOptions)
+		handler.add(err)
+		if handler.aborted() {
+			return nil, handler.err()
+		}
+
+		for _, fi := range dirFileInfos {
+			if fi.IsRegular() && fi.Size > 0 {
+				fileInfos = append(fileInfos, fi)
+			}
+		}
+	}
+
+	sizes := make([]string, len(fileInfos))
+	for i, fi := range fileInfos {
+		sizes[i] = strconv.FormatInt(fi.Size, 10)
+	}
+	groups := groupFiles(fileInfos, sizes)
+
+	groups, ok := skipHardLinks(groups, &handler)
+	if !ok {
+		return nil, handler.err()
+	}
+
+	groups, ok = refineGroups(groups, options.Workers, &handler, hashFileEnds)
+	if !ok {
+		return nil, handler.err()
+	}
+
+	// The hash of the first and last blocks of small files
+	// is the hash of their whole contents.
+	var duplicates, largeGroups [][]*FileInfo
+	for _, group := range groups {
+		if group[0].Size <= 2*duplicatesBlockSize {
+			duplicates = append(duplicates, group)
+		} else {
+			largeGroups = append(largeGroups, group)
+		}
+	}
+
+	largeGroups, ok = refineGroups(largeGroups, options.Workers, &handler, func(fi *FileInfo) (string, error) {
+		return fi.Hash(SHA256)
+	})
+	if ok {
+		duplicates = append(duplicates, largeGroups...)
+	}
+
+	sortDuplicates(duplicates, fileInfos)
+	return duplicates, handler.err()
+}
+
+// groupFiles groups the given files by the given keys
+// and returns the groups of at least two files,
+// keeping the order of the given files.
+func groupFiles(fileInfos []*FileInfo, keys []string) [][]*FileInfo {
+	var order []string
+	groups := make(map[string][]*FileInfo)
+	for i, fi := range fileInfos {
+		key := keys[i]
+		if _, ok := groups[key]; !ok {
+			order = append(order, key)
+		}
+		groups[key] = append(groups[key], fi)
+	}
+
+	var result [][]*FileInfo
+	for _, key := range order {
+		if group := groups[key]; len(group) > 1 {
+			result = append(result, group)
+		}
+	}
+	return result
+}
+
+// skipHardLinks removes from the given groups the files
+// that are hard links to files found before.
+// It returns false if an error stopped processing.
+func skipHardLinks(groups [][]*FileInfo, handler *errorHandler) ([][]*FileInfo, bool) {
+	var result [][]*FileInfo
+	for _, group := range groups {
+		seen := make(map[fileID]bool, len(group))
+		var unique []*FileInfo
+		for _, fi := range group {
+			id, err := statFileID(fi.Path, nil)
+			if err != nil {
+				if handler.handle(fi.Path, err) {
+					return nil, false
+				}
+				continue
+			}
+			if !seen[id] {
+				seen[id] = true
+				unique = append(unique, fi)
+			}
+		}
+
+		if len(unique) > 1 {
+			result = append(result, unique)
+		}
+	}
+
+	return result, true
+}
+
+// refineGroups splits the given groups of files by the hash computed
+// concurrently by the given number of workers with the given function.
+// It returns false if an error stopped processing.
+func refineGroups(groups [][]*FileInfo, workers int, handler *errorHandler, hash func(fi *FileInfo) (string, error)) ([][]*FileInfo, bool) {
+	var fileInfos []*FileInfo
+	for _, group := range groups {
+		fileInfos = append(fileInfos, group...)
+	}
+
+	hashes, errs := hashFiles(fileInfos, workers, hash)
+
+	var result [][]*FileInfo
+	start := 0
+	for _, group := range groups {
+		end := start + len(group)
+
+		var hashed []*FileInfo
+		var keys []string
+		for i := start; i < end; i++ {
+			if errs[i] != nil {
+				if handler.handle(fileInfos[i].Path, errs[i]) {
+					return nil, false
+				}
+				continue
+			}
+			hashed = append(hashed, fileInfos[i])
+			keys = append(keys, hashes[i])
+		}
+
+		result = append(result, groupFiles(hashed, keys)...)
+		start = end
+	}
+
+	return result, true
+}
+
+// hashFileEnds returns the hex SHA-256 digest of the first and last blocks
+// of the given file, which, for files up to two blocks long,
+// is the digest of their whole contents.
+func hashFileEnds(fi *FileInfo) (string, error) {
+	file, err := os.Open(fi.Path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	h := sha256.New()
+	buf := make([]byte, duplicatesBlockSize)
+	n, err := io.ReadFull(file, buf)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return "", err
+	}
+	h.Write(buf[:n])
+
+	if n == duplicatesBlockSize {
+		info, err := file.Stat()
+		if err != nil {
+			return "", err
+		}
+
+		// The last block does not overlap the first one.
+		offset := info.Size() - duplicatesBlockSize
+		if offset < duplicatesBlockSize {
+			offset = duplicatesBlockSize
+		}
+		n, err = file.ReadAt(buf, offset)
+		if err != nil && err != io.EOF {
+			return "", err
+		}
+		h.Write(buf[:n])
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// sortDuplicates sorts the given groups of duplicates
+// following the order of the given files.
+func sortDuplicates(duplicates [][]*FileInfo, fileInfos []*FileInfo) {
+	index := make(map[*FileInfo]int, len(fileInfos))
+	for i, fi := range fileInfos {
+		index[fi] = i
+	}
+
+	sort.Slice(duplicates, func(i, j int) bool {
+		return index[duplicates[i][0]] < index[duplicates[j][0]]
+	})
+}
@@ -0,0 +1,144 @@
+package fs
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindDuplicates(t *testing.T) {
+	assert := assert.New(t)
+
+	dir1, err := ioutil.TempDir("", "dir")
+	assert.Nil(err)
+	defer os.RemoveAll(dir1)
+
+	dir2, err := ioutil.TempDir("", "dir")
+	assert.Nil(err)
+	defer os.RemoveAll(dir2)
+
+	small := make([]byte, 5000)
+	smallEnd := make([]byte, len(small))
+	smallEnd[len(smallEnd)-1] = 1
+	large := make([]byte, 20000)
+	largeMiddle := make([]byte, len(large))
+	largeMiddle[len(largeMiddle)/2] = 1
+
+	files := []struct {
+		filename string
+		data     []byte
+	}{
+		{filepath.Join(dir1, "a.gif"), small},
+		{filepath.Join(dir1, "b.gif"), small},
+		{filepath.Join(dir1, "c.gif"), smallEnd},
+		{filepath.Join(dir1, "d.gif"), large},
+		{filepath.Join(dir1, "e.gif"), large},
+		{filepath.Join(dir1, "f.gif"), largeMiddle},
+		{filepath.Join(dir1, "empty1"), nil},
+		{filepath.Join(dir1, "empty2"), nil},
+		{filepath.Join(dir1, "sub", "name.txt"), []byte("test")},
+		{filepath.Join(dir2, "name(1).txt"), []byte("test")},
+		{filepath.Join(dir2, "other.txt"), []byte("tset")},
+	}
+	assert.Nil(os.Mkdir(filepath.Join(dir1, "sub"), 0755))
+	for _, file := range files {
+		assert.Nil(ioutil.WriteFile(file.filename, file.data, defaultFilePermissions))
+	}
+	assert.Nil(os.Link(filepath.Join(dir1, "a.gif"), filepath.Join(dir1, "g.gif")))
+
+	type args struct {
+		dirnames []string
+		options  *FindDuplicatesOptions
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    [][]string
+		wantErr bool
+	}{
+		{
+			"nil options",
+			args{
+				[]string{dir1},
+				nil,
+			},
+			nil,
+			true,
+		},
+		{
+			"invalid dir",
+			args{
+				[]string{""},
+				&FindDuplicatesOptions{ReadDirOptions: &ReadDirOptions{}},
+			},
+			nil,
+			true,
+		},
+		{
+			"one dir, exclude subdirs",
+			args{
+				[]string{dir1},
+				&FindDuplicatesOptions{ReadDirOptions: &ReadDirOptions{}},
+			},
+			[][]string{
+				{"a.gif", "b.gif"},
+				{"d.gif", "e.gif"},
+			},
+			false,
+		},
+		{
+			"overlapping dirs, include subdirs, one worker",
+			args{
+				[]string{dir1, dir2, dir1},
+				&FindDuplicatesOptions{
+					ReadDirOptions: &ReadDirOptions{
+						IncludeSubdirs: true,
+					},
+					Workers: 1,
+				},
+			},
+			[][]string{
+				{"a.gif", "b.gif"},
+				{"d.gif", "e.gif"},
+				{"sub/name.txt", "name(1).txt"},
+			},
+			false,
+		},
+		{
+			"filter",
+			args{
+				[]string{dir2, dir1},
+				&FindDuplicatesOptions{
+					ReadDirOptions: &ReadDirOptions{
+						IncludeSubdirs: true,
+						Filter: func(fi *FileInfo) bool {
+							return fi.Ext == ".txt"
+						},
+					},
+				},
+			},
+			[][]string{
+				{"name(1).txt", "sub/name.txt"},
+			},
+			false,
+		},
+	}
+	for _, tt := range tests {
+		got, err := FindDuplicates(tt.args.dirnames, tt.args.options)
+		if tt.wantErr {
+			assert.NotNil(err, tt.name)
+			assert.Nil(got, tt.name)
+			continue
+		}
+		assert.Nil(err, tt.name)
+
+		var gotPaths [][]string
+		for _, group := range got {
+			gotPaths = append(gotPaths, relPaths(group))
+		}
+		assert.Equal(tt.want, gotPaths, tt.name)
+	}
+}
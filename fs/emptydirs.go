@@ -0,0 +1,183 @@
+package fs
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// EmptyDirsOptions represents the options available for finding
+// and pruning empty directories.
+type EmptyDirsOptions struct {
+	// IgnoredNames specifies the names of regular files, such as .DS_Store
+	// and Thumbs.db, that are treated as absent. Ignored files are removed
+	// together with the directories containing them when pruning.
+	IgnoredNames []string
+
+	// IncludeRoot, if true, specifies that the root directory should also
+	// be reported and removed if empty. By default the root is never removed.
+	IncludeRoot bool
+
+	// ErrorPolicy and ErrorCallback specify how errors are handled,
+	// as for ReadDir. Directories that cannot be read are never empty.
+	ErrorPolicy   ErrorPolicy
+	ErrorCallback func(pathname string, err error) ErrorPolicy
+}
+
+// emptyDir represents an empty directory and the ignored files it contains.
+type emptyDir struct {
+	fi      *FileInfo
+	ignored []*FileInfo
+}
+
+// FindEmptyDirs returns the empty directories in the directory tree
+// rooted at the given dirname, following the given options.
+// Directories containing only empty directories and ignored files
+// are also empty. Directories are returned bottom-up, deepest first,
+// so that they can be removed in order.
+// Symbolic links are never followed and count as files.
+// Errors are handled as specified by the options and are returned
+// together with the directories found.
+func FindEmptyDirs(dirname string, options *EmptyDirsOptions) ([]*FileInfo, error) {
+	if options == nil {
+		options = &EmptyDirsOptions{}
+	}
+
+	emptyDirs, err := findEmptyDirs(dirname, options)
+	if emptyDirs == nil && err != nil {
+		return nil, err
+	}
+
+	fileInfos := make([]*FileInfo, 0, len(emptyDirs))
+	for _, dir := range emptyDirs {
+		fileInfos = append(fileInfos, dir.fi)
+	}
+	return fileInfos, err
+}
+
+// PruneEmptyDirs removes the empty directories in the directory tree
+// rooted at the given dirname, as found by FindEmptyDirs,
+// together with the ignored files they contain,
+// and returns the directories removed.
+// If a directory cannot be removed, its parents are kept.
+// Errors are handled as specified by the options and are returned
+// together with the directories removed.
+func PruneEmptyDirs(dirname string, options *EmptyDirsOptions) ([]*FileInfo, error) {
+	if options == nil {
+		options = &EmptyDirsOptions{}
+	}
+
+	emptyDirs, err := findEmptyDirs(dirname, options)
+	if emptyDirs == nil && err != nil {
+		return nil, err
+	}
+
+	handler := errorHandler{options: emptyDirsReadOptions(options)}
+	handler.add(err)
+
+	removed := make([]*FileInfo, 0, len(emptyDirs))
+	kept := make(map[string]bool)
+	for _, dir := range emptyDirs {
+		if kept[dir.fi.Path] {
+			kept[dir.fi.Dir] = true
+			continue
+		}
+
+		if err := removeEmptyDir(dir); err != nil {
+			kept[dir.fi.Dir] = true
+			if handler.handle(dir.fi.Path, err) {
+				break
+			}
+			continue
+		}
+		removed = append(removed, dir.fi)
+	}
+
+	return removed, handler.err()
+}
+
+// removeEmptyDir removes the given empty directory and its ignored files.
+func removeEmptyDir(dir *emptyDir) error {
+	for _, fi := range dir.ignored {
+		if err := os.Remove(fi.Path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return os.Remove(dir.fi.Path)
+}
+
+// emptyDirsReadOptions returns the options for reading directory trees
+// when finding empty directories.
+func emptyDirsReadOptions(options *EmptyDirsOptions) *ReadDirOptions {
+	return &ReadDirOptions{
+		IncludeSubdirs:  true,
+		IncludeDirs:     true,
+		IncludeSymlinks: true,
+		IncludeSpecial:  true,
+		Hidden:          IncludeHidden,
+		SkipStat:        true,
+		ErrorPolicy:     options.ErrorPolicy,
+		ErrorCallback:   options.ErrorCallback,
+	}
+}
+
+// findEmptyDirs returns the empty directories in the directory tree
+// rooted at the given dirname, deepest first.
+func findEmptyDirs(dirname string, options *EmptyDirsOptions) ([]*emptyDir, error) {
+	root := filepath.Clean(dirname)
+	ignoredNames := make(map[string]bool, len(options.IgnoredNames))
+	for _, name := range options.IgnoredNames {
+		ignoredNames[name] = true
+	}
+
+	// Directories containing paths with errors are never empty.
+	notEmpty := make(map[string]bool)
+	readOptions := emptyDirsReadOptions(options)
+	readOptions.ErrorCallback = func(pathname string, err error) ErrorPolicy {
+		pathname = filepath.Clean(pathname)
+		notEmpty[pathname] = true
+		notEmpty[filepath.Dir(pathname)] = true
+
+		if options.ErrorCallback != nil {
+			return options.ErrorCallback(pathname, err)
+		}
+		return options.ErrorPolicy
+	}
+
+	// Directories not read because of an abort could be reported as empty.
+	fileInfos, err := ReadDir(root, readOptions)
+	handler := errorHandler{options: readOptions}
+	handler.add(err)
+	if handler.aborted() {
+		return nil, handler.err()
+	}
+
+	sort.SliceStable(fileInfos, func(i, j int) bool {
+		if fileInfos[i].Depth != fileInfos[j].Depth {
+			return fileInfos[i].Depth > fileInfos[j].Depth
+		}
+		return comparePaths(fileInfos[i].Path, fileInfos[j].Path) < 0
+	})
+
+	// Directories are visited after their contents.
+	ignored := make(map[string][]*FileInfo)
+	emptyDirs := make([]*emptyDir, 0)
+	for _, fi := range fileInfos {
+		switch {
+		case fi.IsDir() && !notEmpty[fi.Path]:
+			emptyDirs = append(emptyDirs, &emptyDir{fi: fi, ignored: ignored[fi.Path]})
+		case fi.IsRegular() && ignoredNames[fi.Name]:
+			ignored[fi.Dir] = append(ignored[fi.Dir], fi)
+		default:
+			notEmpty[fi.Dir] = true
+		}
+	}
+
+	if options.IncludeRoot && !notEmpty[root] {
+		fi := newPathInfo(root, os.ModeDir)
+		fi.Root = root
+		emptyDirs = append(emptyDirs, &emptyDir{fi: fi, ignored: ignored[root]})
+	}
+
+	return emptyDirs, err
+}
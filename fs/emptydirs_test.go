@@ -0,0 +1,98 @@
+package fs
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeEmptyDirsTestFiles(dir string) error {
+	for _, subdir := range []string{"a", "b/c", "d/e", "f", "h"} {
+		if err := os.MkdirAll(filepath.Join(dir, filepath.FromSlash(subdir)), 0755); err != nil {
+			return err
+		}
+	}
+	for _, name := range []string{"d/.DS_Store", "d/e/Thumbs.db", "f/file.txt", "h/.hidden"} {
+		if err := ioutil.WriteFile(filepath.Join(dir, filepath.FromSlash(name)), nil, defaultFilePermissions); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestFindEmptyDirs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dir")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	assert.Nil(t, writeEmptyDirsTestFiles(dir))
+
+	tests := []struct {
+		name    string
+		options *EmptyDirsOptions
+		want    []string
+	}{
+		{
+			"no options",
+			nil,
+			[]string{"b/c", "a", "b"},
+		},
+		{
+			"ignored names",
+			&EmptyDirsOptions{IgnoredNames: []string{".DS_Store", "Thumbs.db"}},
+			[]string{"b/c", "d/e", "a", "b", "d"},
+		},
+		{
+			"include root not empty",
+			&EmptyDirsOptions{IncludeRoot: true},
+			[]string{"b/c", "a", "b"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			got, err := FindEmptyDirs(dir, tt.options)
+			assert.Nil(err)
+			assert.Equal(tt.want, relPaths(got))
+		})
+	}
+
+	_, err = FindEmptyDirs(filepath.Join(dir, "missing"), nil)
+	assert.NotNil(t, err)
+}
+
+func TestPruneEmptyDirs(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "dir")
+	assert.Nil(err)
+	defer os.RemoveAll(dir)
+	assert.Nil(writeEmptyDirsTestFiles(dir))
+
+	options := &EmptyDirsOptions{IgnoredNames: []string{".DS_Store", "Thumbs.db"}}
+	removed, err := PruneEmptyDirs(dir, options)
+	assert.Nil(err)
+	assert.Equal([]string{"b/c", "d/e", "a", "b", "d"}, relPaths(removed))
+
+	remaining, err := ReadDir(dir, &ReadDirOptions{IncludeSubdirs: true, IncludeDirs: true})
+	assert.Nil(err)
+	assert.Equal([]string{"f", "f/file.txt", "h", "h/.hidden"}, relPaths(remaining))
+
+	// The root is removed only if included.
+	assert.Nil(os.RemoveAll(filepath.Join(dir, "f")))
+	assert.Nil(os.RemoveAll(filepath.Join(dir, "h")))
+	assert.Nil(os.MkdirAll(filepath.Join(dir, "x", "y"), 0755))
+	removed, err = PruneEmptyDirs(dir, nil)
+	assert.Nil(err)
+	assert.Equal([]string{"x/y", "x"}, relPaths(removed))
+	assert.Nil(AssertDir(dir))
+
+	removed, err = PruneEmptyDirs(dir, &EmptyDirsOptions{IncludeRoot: true})
+	assert.Nil(err)
+	assert.Equal([]string{""}, relPaths(removed))
+	_, err = os.Stat(dir)
+	assert.True(os.IsNotExist(err))
+}
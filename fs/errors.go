@@ -1,5 +1,10 @@
 package fs
 
+import (
+	"fmt"
+	"strings"
+)
+
 // Err represents an error.
 type Err string
 
@@ -23,3 +28,57 @@ const DestFilenameEmptyErr = Err("fs: destination filename cannot be empty")
 
 // SourceDestSameFileErr is the error returned when the source and destination files coincide.
 const SourceDestSameFileErr = Err("fs: source and destination are the same file")
+
+// InvalidCursorErr is the error returned when a cursor given to ReadDirPage is malformed.
+const InvalidCursorErr = Err("fs: invalid cursor")
+
+// CursorOrderErr is the error returned when a cursor given to ReadDirPage
+// was created with different sort options.
+const CursorOrderErr = Err("fs: cursor created with different sort options")
+
+// OverlappingDirsErr is the error returned by CompareDirs when a directory
+// is compared with itself or with one of its subdirectories.
+const OverlappingDirsErr = Err("fs: cannot compare a directory with itself or its subdirectories")
+
+// UnknownHashErr is the error returned when computing a digest
+// with an unknown hash algorithm.
+const UnknownHashErr = Err("fs: unknown hash algorithm")
+
+// NotImageErr is the error returned when reading the image metadata
+// of a file that is not a GIF, PNG or JPEG image.
+const NotImageErr = Err("fs: not a GIF, PNG or JPEG image")
+
+// NoExifErr is the error returned when reading the EXIF metadata
+// of a file that is not a JPEG image or has no EXIF metadata.
+const NoExifErr = Err("fs: no EXIF metadata")
+
+// InvalidExifErr is the error returned when reading
+// malformed EXIF metadata.
+const InvalidExifErr = Err("fs: invalid EXIF metadata")
+
+// PathErr represents an error that occurred on a path.
+type PathErr struct {
+	Path string // path on which the error occurred
+	Err  error  // cause of the error
+}
+
+// Error implements the error interface.
+func (e *PathErr) Error() string {
+	return e.Path + ": " + e.Err.Error()
+}
+
+// MultiErr represents a list of errors that occurred on different paths.
+type MultiErr []*PathErr
+
+// Error implements the error interface.
+func (e MultiErr) Error() string {
+	if len(e) == 1 {
+		return "fs: 1 error occurred: " + e[0].Error()
+	}
+
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return fmt.Sprintf("fs: %d errors occurred: %s", len(e), strings.Join(msgs, "; "))
+}
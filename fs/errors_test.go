@@ -1,6 +1,7 @@
 package fs
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -25,3 +26,53 @@ func TestErr_Error(t *testing.T) {
 		assert.Equal(tt.want, got, tt.name)
 	}
 }
+
+func TestPathErr_Error(t *testing.T) {
+	assert := assert.New(t)
+
+	tests := []struct {
+		name string
+		e    *PathErr
+		want string
+	}{
+		{
+			"path error",
+			&PathErr{Path: "dir", Err: errors.New("permission denied")},
+			"dir: permission denied",
+		},
+	}
+	for _, tt := range tests {
+		got := tt.e.Error()
+		assert.Equal(tt.want, got, tt.name)
+	}
+}
+
+func TestMultiErr_Error(t *testing.T) {
+	assert := assert.New(t)
+
+	tests := []struct {
+		name string
+		e    MultiErr
+		want string
+	}{
+		{
+			"single error",
+			MultiErr{
+				&PathErr{Path: "dir", Err: errors.New("permission denied")},
+			},
+			"fs: 1 error occurred: dir: permission denied",
+		},
+		{
+			"multiple errors",
+			MultiErr{
+				&PathErr{Path: "dir", Err: errors.New("permission denied")},
+				&PathErr{Path: "file", Err: errors.New("no such file")},
+			},
+			"fs: 2 errors occurred: dir: permission denied; file: no such file",
+		},
+	}
+	for _, tt := range tests {
+		got := tt.e.Error()
+		assert.Equal(tt.want, got, tt.name)
+	}
+}
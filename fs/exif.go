@@ -0,0 +1,320 @@
+package fs
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// EXIF tags read by ReadExif.
+const (
+	exifTagMake               = 0x010F
+	exifTagModel              = 0x0110
+	exifTagOrientation        = 0x0112
+	exifTagExifIFD            = 0x8769
+	exifTagGPSIFD             = 0x8825
+	exifTagDateTimeOriginal   = 0x9003
+	exifTagOffsetTimeOriginal = 0x9011
+	exifTagGPSLatitude        = 0x0002
+)
+
+// exifHeader is the header of the JPEG APP1 segment holding EXIF metadata.
+const exifHeader = "Exif\x00\x00"
+
+// ExifInfo represents the EXIF metadata of a JPEG image.
+type ExifInfo struct {
+	// DateTimeOriginal is the time at which the image was captured,
+	// or the zero time if not available.
+	// Times without an offset from UTC are in the local time zone.
+	DateTimeOriginal time.Time
+
+	// Orientation is the EXIF orientation, from 1 to 8,
+	// or 0 if not available.
+	Orientation int
+
+	Make   string // camera manufacturer
+	Model  string // camera model
+	HasGPS bool   // true if GPS coordinates are present
+}
+
+// ReadExif returns the EXIF metadata of the JPEG image with the given filename.
+// If the file is not a JPEG image or has no EXIF metadata,
+// ReadExif returns NoExifErr; if the metadata is malformed,
+// ReadExif returns InvalidExifErr.
+func ReadExif(filename string) (*ExifInfo, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	data, err := readExifSegment(bufio.NewReader(file))
+	if err != nil {
+		return nil, err
+	}
+
+	return parseExif(data)
+}
+
+// ReadExif returns the EXIF metadata of the file,
+// as described by the package-level ReadExif.
+// The metadata is cached in Exif and read only if not already set.
+func (fi *FileInfo) ReadExif() (*ExifInfo, error) {
+	if fi.Exif != nil {
+		return fi.Exif, nil
+	}
+
+	info, err := ReadExif(fi.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	fi.Exif = info
+	return info, nil
+}
+
+// readExifSegment returns the TIFF structure holding the EXIF metadata
+// of the JPEG image read from the given reader.
+func readExifSegment(r *bufio.Reader) ([]byte, error) {
+	var soi [2]byte
+	if _, err := io.ReadFull(r, soi[:]); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil, NoExifErr
+		}
+		return nil, err
+	}
+	if soi != [2]byte{0xFF, 0xD8} {
+		return nil, NoExifErr
+	}
+
+	for {
+		marker, err := readJPEGMarker(r)
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case marker == 0xDA || marker == 0xD9:
+			// Metadata segments come before the start of scan.
+			return nil, NoExifErr
+		case marker == 0x01 || (marker >= 0xD0 && marker <= 0xD7):
+			// Markers without segments.
+			continue
+		}
+
+		var length [2]byte
+		if _, err := io.ReadFull(r, length[:]); err != nil {
+			return nil, exifReadErr(err)
+		}
+		size := int(binary.BigEndian.Uint16(length[:])) - 2
+		if size < 0 {
+			return nil, InvalidExifErr
+		}
+
+		if marker != 0xE1 {
+			if _, err := r.Discard(size); err != nil {
+				return nil, exifReadErr(err)
+			}
+			continue
+		}
+
+		segment := make([]byte, size)
+		if _, err := io.ReadFull(r, segment); err != nil {
+			return nil, exifReadErr(err)
+		}
+		// APP1 segments also hold other metadata, such as XMP.
+		if bytes.HasPrefix(segment, []byte(exifHeader)) {
+			return segment[len(exifHeader):], nil
+		}
+	}
+}
+
+// readJPEGMarker reads the next JPEG marker, skipping fill bytes.
+func readJPEGMarker(r *bufio.Reader) (byte, error) {
+	b, err := r.ReadByte()
+	if err != nil {
+		return 0, exifReadErr(err)
+	}
+	if b != 0xFF {
+		return 0, InvalidExifErr
+	}
+
+	for b == 0xFF {
+		if b, err = r.ReadByte(); err != nil {
+			return 0, exifReadErr(err)
+		}
+	}
+	return b, nil
+}
+
+// exifReadErr returns InvalidExifErr if the given error
+// is caused by a truncated file, or the error itself otherwise.
+func exifReadErr(err error) error {
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return InvalidExifErr
+	}
+	return err
+}
+
+// parseExif parses the EXIF metadata held by the given TIFF structure.
+func parseExif(data []byte) (*ExifInfo, error) {
+	if len(data) < 8 {
+		return nil, InvalidExifErr
+	}
+
+	t := &tiffReader{data: data}
+	switch string(data[:2]) {
+	case "II":
+		t.order = binary.LittleEndian
+	case "MM":
+		t.order = binary.BigEndian
+	default:
+		return nil, InvalidExifErr
+	}
+	if t.order.Uint16(data[2:]) != 42 {
+		return nil, InvalidExifErr
+	}
+
+	ifd0, err := t.readIFD(t.order.Uint32(data[4:]))
+	if err != nil {
+		return nil, err
+	}
+
+	info := &ExifInfo{
+		Make:  ifd0[exifTagMake].string(),
+		Model: ifd0[exifTagModel].string(),
+	}
+	if orientation := ifd0[exifTagOrientation].uint(t.order); orientation >= 1 && orientation <= 8 {
+		info.Orientation = int(orientation)
+	}
+
+	if entry, ok := ifd0[exifTagExifIFD]; ok {
+		exifIFD, err := t.readIFD(entry.uint(t.order))
+		if err != nil {
+			return nil, err
+		}
+		info.DateTimeOriginal = parseExifTime(
+			exifIFD[exifTagDateTimeOriginal].string(),
+			exifIFD[exifTagOffsetTimeOriginal].string(),
+		)
+	}
+
+	if entry, ok := ifd0[exifTagGPSIFD]; ok {
+		gpsIFD, err := t.readIFD(entry.uint(t.order))
+		if err != nil {
+			return nil, err
+		}
+		_, info.HasGPS = gpsIFD[exifTagGPSLatitude]
+	}
+
+	return info, nil
+}
+
+// parseExifTime parses the given EXIF date and time and offset from UTC
+// and returns the zero time if they are not valid.
+func parseExifTime(dateTime, offset string) time.Time {
+	if offset != "" {
+		if t, err := time.Parse("2006:01:02 15:04:05-07:00", dateTime+offset); err == nil {
+			return t
+		}
+	}
+
+	t, err := time.ParseInLocation("2006:01:02 15:04:05", dateTime, time.Local)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+// tiffReader reads the image file directories of a TIFF structure.
+type tiffReader struct {
+	data  []byte
+	order binary.ByteOrder
+}
+
+// tiffEntry represents an entry of an image file directory.
+type tiffEntry struct {
+	typ   uint16
+	count uint32
+	value []byte
+}
+
+// tiffTypeSizes holds the sizes in bytes of the TIFF field types.
+var tiffTypeSizes = map[uint16]uint64{
+	1:  1, // BYTE
+	2:  1, // ASCII
+	3:  2, // SHORT
+	4:  4, // LONG
+	5:  8, // RATIONAL
+	7:  1, // UNDEFINED
+	9:  4, // SLONG
+	10: 8, // SRATIONAL
+}
+
+// readIFD returns the entries, by tag, of the image file directory
+// at the given offset. Entries with unknown types are skipped.
+func (t *tiffReader) readIFD(offset uint32) (map[uint16]tiffEntry, error) {
+	start := uint64(offset)
+	if start+2 > uint64(len(t.data)) {
+		return nil, InvalidExifErr
+	}
+
+	count := uint64(t.order.Uint16(t.data[start:]))
+	start += 2
+	if start+count*12 > uint64(len(t.data)) {
+		return nil, InvalidExifErr
+	}
+
+	entries := make(map[uint16]tiffEntry, count)
+	for i := uint64(0); i < count; i++ {
+		raw := t.data[start+i*12 : start+(i+1)*12]
+		entry := tiffEntry{
+			typ:   t.order.Uint16(raw[2:]),
+			count: t.order.Uint32(raw[4:]),
+		}
+
+		typeSize, ok := tiffTypeSizes[entry.typ]
+		if !ok {
+			continue
+		}
+
+		// Values up to 4 bytes long are held by the entry itself.
+		size := typeSize * uint64(entry.count)
+		if size <= 4 {
+			entry.value = raw[8 : 8+size]
+		} else {
+			valueOffset := uint64(t.order.Uint32(raw[8:]))
+			if valueOffset+size > uint64(len(t.data)) {
+				return nil, InvalidExifErr
+			}
+			entry.value = t.data[valueOffset : valueOffset+size]
+		}
+
+		entries[t.order.Uint16(raw)] = entry
+	}
+
+	return entries, nil
+}
+
+// string returns the value of an ASCII entry.
+func (e tiffEntry) string() string {
+	if e.typ != 2 {
+		return ""
+	}
+	return strings.TrimRight(string(e.value), "\x00 ")
+}
+
+// uint returns the first value of a SHORT or LONG entry.
+func (e tiffEntry) uint(order binary.ByteOrder) uint32 {
+	switch {
+	case e.typ == 3 && len(e.value) >= 2:
+		return uint32(order.Uint16(e.value))
+	case e.typ == 4 && len(e.value) >= 4:
+		return order.Uint32(e.value)
+	}
+	return 0
+}
@@ -0,0 +1,294 @@
+package fs
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/jpeg"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testIFDEntry represents an entry of an image file directory in test data.
+type testIFDEntry struct {
+	tag   uint16
+	typ   uint16
+	count uint32
+	value []byte
+}
+
+func asciiEntry(tag uint16, s string) testIFDEntry {
+	return testIFDEntry{tag, 2, uint32(len(s) + 1), append([]byte(s), 0)}
+}
+
+func shortEntry(order binary.ByteOrder, tag, v uint16) testIFDEntry {
+	value := make([]byte, 2)
+	order.PutUint16(value, v)
+	return testIFDEntry{tag, 3, 1, value}
+}
+
+func longEntry(order binary.ByteOrder, tag uint16, v uint32) testIFDEntry {
+	value := make([]byte, 4)
+	order.PutUint32(value, v)
+	return testIFDEntry{tag, 4, 1, value}
+}
+
+// encodeIFD encodes an image file directory at the given offset
+// followed by the values longer than 4 bytes.
+func encodeIFD(order binary.ByteOrder, offset uint32, entries []testIFDEntry) []byte {
+	var ifd, values bytes.Buffer
+	valuesOffset := offset + 2 + uint32(len(entries))*12 + 4
+
+	_ = binary.Write(&ifd, order, uint16(len(entries)))
+	for _, e := range entries {
+		_ = binary.Write(&ifd, order, e.tag)
+		_ = binary.Write(&ifd, order, e.typ)
+		_ = binary.Write(&ifd, order, e.count)
+		if len(e.value) <= 4 {
+			ifd.Write(e.value)
+			ifd.Write(make([]byte, 4-len(e.value)))
+			continue
+		}
+		_ = binary.Write(&ifd, order, valuesOffset+uint32(values.Len()))
+		values.Write(e.value)
+	}
+	_ = binary.Write(&ifd, order, uint32(0))
+
+	return append(ifd.Bytes(), values.Bytes()...)
+}
+
+// testExifSegment returns the contents of an EXIF APP1 segment
+// with the given IFD0, EXIF IFD and GPS IFD entries.
+func testExifSegment(order binary.ByteOrder, ifd0, exifIFD, gpsIFD []testIFDEntry) []byte {
+	header := []byte("MM\x00\x2a\x00\x00\x00\x08")
+	if order == binary.LittleEndian {
+		header = []byte("II\x2a\x00\x08\x00\x00\x00")
+	}
+
+	// Encode IFD0 with placeholder pointers to compute its size.
+	withPointers := func(exifOffset, gpsOffset uint32) []testIFDEntry {
+		entries := append([]testIFDEntry{}, ifd0...)
+		if exifIFD != nil {
+			entries = append(entries, longEntry(order, exifTagExifIFD, exifOffset))
+		}
+		if gpsIFD != nil {
+			entries = append(entries, longEntry(order, exifTagGPSIFD, gpsOffset))
+		}
+		return entries
+	}
+	exifOffset := 8 + uint32(len(encodeIFD(order, 8, withPointers(0, 0))))
+	exifData := encodeIFD(order, exifOffset, exifIFD)
+	gpsOffset := exifOffset + uint32(len(exifData))
+	gpsData := encodeIFD(order, gpsOffset, gpsIFD)
+
+	data := append([]byte(exifHeader), header...)
+	data = append(data, encodeIFD(order, 8, withPointers(exifOffset, gpsOffset))...)
+	if exifIFD != nil {
+		data = append(data, exifData...)
+	}
+	if gpsIFD != nil {
+		data = append(data, gpsData...)
+	}
+	return data
+}
+
+// testJPEG returns a JPEG image with the given APP1 segments.
+func testJPEG(segments ...[]byte) []byte {
+	var buf bytes.Buffer
+	_ = jpeg.Encode(&buf, image.NewGray(image.Rect(0, 0, 2, 2)), nil)
+	img := buf.Bytes()
+
+	data := append([]byte{}, img[:2]...)
+	for _, segment := range segments {
+		data = append(data, 0xFF, 0xE1, byte((len(segment)+2)>>8), byte(len(segment)+2))
+		data = append(data, segment...)
+	}
+	return append(data, img[2:]...)
+}
+
+func TestReadExif(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "dir")
+	assert.Nil(err)
+	defer os.RemoveAll(dir)
+
+	be, le := binary.BigEndian, binary.LittleEndian
+	full := testExifSegment(be,
+		[]testIFDEntry{
+			asciiEntry(exifTagMake, "Canon"),
+			asciiEntry(exifTagModel, "Canon EOS 5D"),
+			shortEntry(be, exifTagOrientation, 6),
+		},
+		[]testIFDEntry{
+			asciiEntry(exifTagDateTimeOriginal, "2019:07:14 10:30:15"),
+			asciiEntry(exifTagOffsetTimeOriginal, "+02:00"),
+		},
+		[]testIFDEntry{
+			{exifTagGPSLatitude, 5, 3, make([]byte, 24)},
+		},
+	)
+	localTime := testExifSegment(le,
+		[]testIFDEntry{
+			asciiEntry(exifTagModel, "Pixel 3"),
+		},
+		[]testIFDEntry{
+			asciiEntry(exifTagDateTimeOriginal, "2018:12:31 23:59:59"),
+		},
+		[]testIFDEntry{},
+	)
+	noDate := testExifSegment(le,
+		[]testIFDEntry{
+			shortEntry(le, exifTagOrientation, 1),
+		},
+		[]testIFDEntry{
+			asciiEntry(exifTagDateTimeOriginal, "    :  :     :  :  "),
+		},
+		nil,
+	)
+	badOrientation := testExifSegment(be,
+		[]testIFDEntry{
+			shortEntry(be, exifTagOrientation, 9),
+		},
+		nil,
+		nil,
+	)
+	badOffset := testExifSegment(be, nil, nil, nil)
+	badOffset = append(badOffset[:len(exifHeader)+4], 0xFF, 0xFF, 0xFF, 0x00)
+
+	tests := []struct {
+		name    string
+		data    []byte
+		want    *ExifInfo
+		wantErr error
+	}{
+		{
+			"big endian, all metadata",
+			testJPEG([]byte("http://ns.adobe.com/xap/1.0/\x00<x:xmpmeta/>"), full),
+			&ExifInfo{
+				DateTimeOriginal: time.Date(2019, 7, 14, 10, 30, 15, 0, time.FixedZone("", 2*60*60)),
+				Orientation:      6,
+				Make:             "Canon",
+				Model:            "Canon EOS 5D",
+				HasGPS:           true,
+			},
+			nil,
+		},
+		{
+			"little endian, local time, no GPS coordinates",
+			testJPEG(localTime),
+			&ExifInfo{
+				DateTimeOriginal: time.Date(2018, 12, 31, 23, 59, 59, 0, time.Local),
+				Model:            "Pixel 3",
+			},
+			nil,
+		},
+		{
+			"blank date",
+			testJPEG(noDate),
+			&ExifInfo{
+				Orientation: 1,
+			},
+			nil,
+		},
+		{
+			"invalid orientation",
+			testJPEG(badOrientation),
+			&ExifInfo{},
+			nil,
+		},
+		{
+			"no EXIF",
+			testJPEG(),
+			nil,
+			NoExifErr,
+		},
+		{
+			"not a JPEG",
+			[]byte("GIF89a\x01\x00\x01\x00"),
+			nil,
+			NoExifErr,
+		},
+		{
+			"empty",
+			nil,
+			nil,
+			NoExifErr,
+		},
+		{
+			"invalid byte order",
+			testJPEG([]byte(exifHeader + "XX\x00\x2a\x00\x00\x00\x08")),
+			nil,
+			InvalidExifErr,
+		},
+		{
+			"invalid IFD offset",
+			testJPEG(badOffset),
+			nil,
+			InvalidExifErr,
+		},
+		{
+			"truncated",
+			testJPEG(full)[:40],
+			nil,
+			InvalidExifErr,
+		},
+	}
+	for _, tt := range tests {
+		filename := filepath.Join(dir, "image.jpg")
+		assert.Nil(ioutil.WriteFile(filename, tt.data, defaultFilePermissions), tt.name)
+
+		got, err := ReadExif(filename)
+		assert.Equal(tt.wantErr, err, tt.name)
+		if tt.want == nil {
+			assert.Nil(got, tt.name)
+			continue
+		}
+		assert.True(tt.want.DateTimeOriginal.Equal(got.DateTimeOriginal), tt.name)
+		got.DateTimeOriginal = tt.want.DateTimeOriginal
+		assert.Equal(tt.want, got, tt.name)
+	}
+
+	_, err = ReadExif(filepath.Join(dir, "missing.jpg"))
+	assert.True(os.IsNotExist(err))
+}
+
+func TestReadDir_exif(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "dir")
+	assert.Nil(err)
+	defer os.RemoveAll(dir)
+
+	segment := testExifSegment(binary.BigEndian,
+		[]testIFDEntry{asciiEntry(exifTagModel, "Canon EOS 5D")},
+		[]testIFDEntry{asciiEntry(exifTagDateTimeOriginal, "2019:07:14 10:30:15")},
+		nil,
+	)
+	assert.Nil(ioutil.WriteFile(filepath.Join(dir, "1.jpg"), testJPEG(segment), defaultFilePermissions))
+	assert.Nil(ioutil.WriteFile(filepath.Join(dir, "2.jpg"), testJPEG(), defaultFilePermissions))
+	assert.Nil(ioutil.WriteFile(filepath.Join(dir, "3.txt"), []byte("hello"), defaultFilePermissions))
+
+	capturedIn2019 := func(fi *FileInfo) bool {
+		return fi.Exif != nil && fi.Exif.DateTimeOriginal.Year() == 2019
+	}
+	fileInfos, err := ReadDir(dir, &ReadDirOptions{ReadExif: true})
+	assert.Nil(err)
+	assert.Equal([]string{"1.jpg", "2.jpg", "3.txt"}, relPaths(fileInfos))
+	assert.Equal("Canon EOS 5D", fileInfos[0].Exif.Model)
+	assert.Nil(fileInfos[1].Exif)
+	assert.Nil(fileInfos[2].Exif)
+
+	fileInfos, err = ReadDir(dir, &ReadDirOptions{ReadExif: true, Filter: capturedIn2019})
+	assert.Nil(err)
+	assert.Equal([]string{"1.jpg"}, relPaths(fileInfos))
+
+	fileInfos, err = ReadDir(dir, &ReadDirOptions{Filter: capturedIn2019})
+	assert.Nil(err)
+	assert.Empty(fileInfos)
+}
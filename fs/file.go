@@ -6,17 +6,63 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 )
 
 const defaultFilePermissions = 0644
 
-// FileInfo represents the information available on a regular file.
+// FileInfo represents the information available on a file.
 type FileInfo struct {
-	Name string // base name of the file
-	Ext  string // file extension
-	Dir  string // directory containing the file
-	Path string // full file path
-	Size int64  // file size in bytes
+	Name    string      // base name of the file
+	Stem    string      // base name of the file without extension
+	Ext     string      // file extension
+	Dir     string      // directory containing the file
+	Path    string      // full file path
+	Size    int64       // file size in bytes
+	Mode    os.FileMode // file mode bits, including type and permissions
+	Type    os.FileMode // file type bits (see os.ModeType), 0 for regular files
+	ModTime time.Time   // modification time
+	Depth   int         // nesting level, relative to the directory read by ReadDir
+	Hidden  bool        // true if the file name starts with a dot
+
+	// AccessTime and ChangeTime are the times of the last access
+	// and of the last status change. They are only set on Linux.
+	AccessTime time.Time
+	ChangeTime time.Time
+
+	// Ownership and storage information, only set on Linux.
+	UID    uint32 // user ID of the owner
+	GID    uint32 // group ID of the owner
+	Inode  uint64 // inode number
+	Dev    uint64 // device containing the file
+	Nlink  uint64 // number of hard links
+	Blocks int64  // number of 512-byte blocks allocated
+
+	// Root is the directory read by ReadDir and RelPath is the file path
+	// relative to Root, using slashes as separators.
+	// They are only set by ReadDir.
+	Root    string
+	RelPath string
+
+	// RealPath is the file path with symbolic links resolved.
+	// It is only set by ReadDir when following symbolic links.
+	RealPath string
+
+	// Hashes holds the hex digests of the file contents by algorithm.
+	// Digests are computed by Hash or by ReadDir with the Hash option.
+	Hashes map[HashAlgorithm]string
+
+	// ContentType is the MIME type detected from the file contents.
+	// It is set by DetectContentType or by ReadDir with ContentTypes.
+	ContentType string
+
+	// Image holds the metadata of GIF, PNG and JPEG images.
+	// It is set by ReadImageInfo or by ReadDir with image size filters.
+	Image *ImageInfo
+
+	// Exif holds the EXIF metadata of JPEG images.
+	// It is set by ReadExif or by ReadDir with ReadExif.
+	Exif *ExifInfo
 }
 
 // MoveFileSafe moves the file with the given filename to the given destination.
@@ -229,16 +275,93 @@ func ReadFileInfo(filename string) (*FileInfo, error) {
 		return nil, fmt.Errorf("%q is not a regular file", filename)
 	}
 
-	name := info.Name()
+	return newFileInfo(filename, info), nil
+}
+
+// readEntryInfo returns the information available on a file of any type.
+// If the file is a symbolic link, readEntryInfo describes the link itself.
+func readEntryInfo(filename string) (*FileInfo, error) {
+	info, err := os.Lstat(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	return newFileInfo(filename, info), nil
+}
+
+func newFileInfo(filename string, info os.FileInfo) *FileInfo {
+	fi := newPathInfo(filename, info.Mode()&os.ModeType)
+	fi.setStat(info)
+	return fi
+}
+
+// newPathInfo returns the information available on a file
+// of the given type from its filename, without reading the filesystem.
+func newPathInfo(filename string, typ os.FileMode) *FileInfo {
 	path := filepath.Clean(filename)
-	fi := &FileInfo{
-		Name: name,
-		Ext:  filepath.Ext(name),
-		Dir:  filepath.Dir(path),
-		Path: path,
-		Size: info.Size(),
-	}
-	return fi, nil
+	name := filepath.Base(path)
+	ext := filepath.Ext(name)
+	return &FileInfo{
+		Name:   name,
+		Stem:   strings.TrimSuffix(name, ext),
+		Ext:    ext,
+		Dir:    filepath.Dir(path),
+		Path:   path,
+		Type:   typ,
+		Hidden: isHidden(name),
+	}
+}
+
+// isHidden returns true if the given file name is the name of a hidden file.
+func isHidden(name string) bool {
+	return strings.HasPrefix(name, ".") && name != "." && name != ".."
+}
+
+// Stat reads from the filesystem the information on the file
+// that is not available from its path, such as its size and modification time.
+// Stat is useful to complete the information read by ReadDir with SkipStat.
+// Symbolic links are followed, unless the file is itself described as a link.
+func (fi *FileInfo) Stat() error {
+	info, err := fi.stat()
+	if err != nil {
+		return err
+	}
+
+	fi.setStat(info)
+	return nil
+}
+
+// stat returns the information on the file read from the filesystem
+// as described by Stat.
+func (fi *FileInfo) stat() (os.FileInfo, error) {
+	if fi.IsSymlink() {
+		return os.Lstat(fi.Path)
+	}
+	return os.Stat(fi.Path)
+}
+
+// setStat sets the information read from the filesystem.
+func (fi *FileInfo) setStat(info os.FileInfo) {
+	fi.Size = info.Size()
+	fi.Mode = info.Mode()
+	fi.Type = info.Mode() & os.ModeType
+	fi.ModTime = info.ModTime()
+	fi.setSysStat(info)
+}
+
+// IsRegular returns true if the file is a regular file.
+func (fi *FileInfo) IsRegular() bool {
+	return fi.Type&os.ModeType == 0
+}
+
+// IsDir returns true if the file is a directory.
+func (fi *FileInfo) IsDir() bool {
+	return fi.Type&os.ModeDir != 0
+}
+
+// IsSymlink returns true if the file is a symbolic link.
+func (fi *FileInfo) IsSymlink() bool {
+	return fi.Type&os.ModeSymlink != 0
 }
 
 // AssertFile returns an error if the given filename is not a regular file.
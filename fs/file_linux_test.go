@@ -0,0 +1,40 @@
+package fs
+
+import (
+	"io/ioutil"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadFileInfo_linux(t *testing.T) {
+	assert := assert.New(t)
+
+	file1, err := ioutil.TempFile("", "file*.txt")
+	assert.Nil(err)
+	_, err = file1.WriteString("test")
+	assert.Nil(err)
+	file1.Close()
+	defer os.Remove(file1.Name())
+	assert.Nil(os.Chmod(file1.Name(), 0600))
+	assert.Nil(os.Link(file1.Name(), file1.Name()+".link"))
+	defer os.Remove(file1.Name() + ".link")
+
+	var stat syscall.Stat_t
+	assert.Nil(syscall.Stat(file1.Name(), &stat))
+
+	fi, err := ReadFileInfo(file1.Name())
+	assert.Nil(err)
+	assert.Equal(os.FileMode(0600), fi.Mode)
+	assert.Equal(time.Unix(int64(stat.Atim.Sec), int64(stat.Atim.Nsec)), fi.AccessTime)
+	assert.Equal(time.Unix(int64(stat.Ctim.Sec), int64(stat.Ctim.Nsec)), fi.ChangeTime)
+	assert.Equal(uint32(os.Getuid()), fi.UID)
+	assert.Equal(uint32(os.Getgid()), fi.GID)
+	assert.Equal(uint64(stat.Ino), fi.Inode)
+	assert.Equal(uint64(stat.Dev), fi.Dev)
+	assert.Equal(uint64(2), fi.Nlink)
+	assert.Equal(int64(stat.Blocks), fi.Blocks)
+}
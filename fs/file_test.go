@@ -123,6 +123,16 @@ func TestReadFileInfo(t *testing.T) {
 	assert.Nil(err)
 	file1.Close()
 	defer os.Remove(file1.Name())
+	file1Info, err := os.Stat(file1.Name())
+	assert.Nil(err)
+	file1Contents := &FileInfo{
+		Name: filepath.Base(file1.Name()),
+		Stem: strings.TrimSuffix(filepath.Base(file1.Name()), ".txt"),
+		Ext:  ".txt",
+		Dir:  filepath.Dir(file1.Name()),
+		Path: file1.Name(),
+	}
+	file1Contents.setStat(file1Info)
 
 	type args struct {
 		filename string
@@ -154,13 +164,7 @@ func TestReadFileInfo(t *testing.T) {
 			args{
 				file1.Name(),
 			},
-			&FileInfo{
-				Name: filepath.Base(file1.Name()),
-				Ext:  filepath.Ext(file1.Name()),
-				Dir:  filepath.Dir(file1.Name()),
-				Path: file1.Name(),
-				Size: 0,
-			},
+			file1Contents,
 			false,
 		},
 	}
@@ -171,6 +175,115 @@ func TestReadFileInfo(t *testing.T) {
 	}
 }
 
+func TestFileInfo_types(t *testing.T) {
+	assert := assert.New(t)
+
+	tests := []struct {
+		name        string
+		fi          *FileInfo
+		wantRegular bool
+		wantDir     bool
+		wantSymlink bool
+	}{
+		{
+			"regular file",
+			&FileInfo{Type: 0},
+			true,
+			false,
+			false,
+		},
+		{
+			"directory",
+			&FileInfo{Type: os.ModeDir},
+			false,
+			true,
+			false,
+		},
+		{
+			"symbolic link",
+			&FileInfo{Type: os.ModeSymlink},
+			false,
+			false,
+			true,
+		},
+		{
+			"named pipe",
+			&FileInfo{Type: os.ModeNamedPipe},
+			false,
+			false,
+			false,
+		},
+	}
+	for _, tt := range tests {
+		assert.Equal(tt.wantRegular, tt.fi.IsRegular(), tt.name)
+		assert.Equal(tt.wantDir, tt.fi.IsDir(), tt.name)
+		assert.Equal(tt.wantSymlink, tt.fi.IsSymlink(), tt.name)
+	}
+}
+
+func Test_newPathInfo(t *testing.T) {
+	assert := assert.New(t)
+
+	tests := []struct {
+		filename   string
+		wantName   string
+		wantStem   string
+		wantExt    string
+		wantHidden bool
+	}{
+		{"dir/file.gif", "file.gif", "file", ".gif", false},
+		{"dir/file.tar.gz", "file.tar.gz", "file.tar", ".gz", false},
+		{"dir/file", "file", "file", "", false},
+		{"dir/.hidden.txt", ".hidden.txt", ".hidden", ".txt", true},
+		{"dir/.hidden", ".hidden", "", ".hidden", true},
+	}
+	for _, tt := range tests {
+		got := newPathInfo(filepath.FromSlash(tt.filename), 0)
+		assert.Equal(tt.wantName, got.Name, tt.filename)
+		assert.Equal(tt.wantStem, got.Stem, tt.filename)
+		assert.Equal(tt.wantExt, got.Ext, tt.filename)
+		assert.Equal(tt.wantHidden, got.Hidden, tt.filename)
+	}
+}
+
+func TestFileInfo_Stat(t *testing.T) {
+	assert := assert.New(t)
+
+	file1, err := ioutil.TempFile("", "file*.txt")
+	assert.Nil(err)
+	_, err = file1.WriteString("test")
+	assert.Nil(err)
+	file1.Close()
+	defer os.Remove(file1.Name())
+	file1Info, err := ReadFileInfo(file1.Name())
+	assert.Nil(err)
+
+	tests := []struct {
+		name    string
+		fi      *FileInfo
+		want    *FileInfo
+		wantErr bool
+	}{
+		{
+			"non-existing file",
+			newPathInfo(file1.Name()+".missing", 0),
+			newPathInfo(file1.Name()+".missing", 0),
+			true,
+		},
+		{
+			"regular file",
+			newPathInfo(file1.Name(), 0),
+			file1Info,
+			false,
+		},
+	}
+	for _, tt := range tests {
+		gotErr := tt.fi.Stat()
+		assert.Equal(tt.wantErr, gotErr != nil, tt.name)
+		assert.Equal(tt.want, tt.fi, tt.name)
+	}
+}
+
 func TestCreateFile(t *testing.T) {
 	assert := assert.New(t)
 
@@ -0,0 +1,27 @@
+//go:build !windows
+// +build !windows
+
+package fs
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// fileID uniquely identifies a file within a system.
+type fileID struct {
+	dev uint64 // device containing the file
+	ino uint64 // file serial number
+}
+
+// getFileID returns the identifier of the file with the given filename
+// and the given information.
+func getFileID(filename string, info os.FileInfo) (fileID, error) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return fileID{}, fmt.Errorf("%q has no device and inode numbers", filename)
+	}
+
+	return fileID{dev: uint64(stat.Dev), ino: uint64(stat.Ino)}, nil
+}
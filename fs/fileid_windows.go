@@ -0,0 +1,47 @@
+package fs
+
+import (
+	"os"
+	"syscall"
+)
+
+// fileID uniquely identifies a file within a system.
+type fileID struct {
+	dev uint64 // serial number of the volume containing the file
+	ino uint64 // file index within the volume
+}
+
+// getFileID returns the identifier of the file with the given filename
+// and the given information.
+func getFileID(filename string, _ os.FileInfo) (fileID, error) {
+	pathp, err := syscall.UTF16PtrFromString(filename)
+	if err != nil {
+		return fileID{}, err
+	}
+
+	// FILE_FLAG_BACKUP_SEMANTICS is required to open directories,
+	// see https://golang.org/src/os/types_windows.go
+	handle, err := syscall.CreateFile(
+		pathp,
+		0,
+		0,
+		nil,
+		syscall.OPEN_EXISTING,
+		syscall.FILE_FLAG_BACKUP_SEMANTICS,
+		0,
+	)
+	if err != nil {
+		return fileID{}, err
+	}
+	defer syscall.CloseHandle(handle)
+
+	var data syscall.ByHandleFileInformation
+	if err := syscall.GetFileInformationByHandle(handle, &data); err != nil {
+		return fileID{}, err
+	}
+
+	return fileID{
+		dev: uint64(data.VolumeSerialNumber),
+		ino: uint64(data.FileIndexHigh)<<32 | uint64(data.FileIndexLow),
+	}, nil
+}
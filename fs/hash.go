@@ -0,0 +1,156 @@
+package fs
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"hash/crc32"
+	"hash/fnv"
+	"io"
+	"os"
+	"runtime"
+	"sync"
+)
+
+// HashAlgorithm represents an algorithm computing digests of file contents.
+type HashAlgorithm int
+
+const (
+	// NoHash computes no digest.
+	NoHash HashAlgorithm = iota
+
+	// SHA256 computes SHA-256 digests.
+	SHA256
+
+	// SHA1 computes SHA-1 digests.
+	SHA1
+
+	// MD5 computes MD5 digests.
+	MD5
+
+	// CRC32 computes CRC-32 checksums with the IEEE polynomial.
+	CRC32
+
+	// FNV64a computes 64-bit FNV-1a hashes, which are fast
+	// but not suitable for detecting deliberate changes.
+	FNV64a
+)
+
+var hashNames = [...]string{
+	NoHash: "none",
+	SHA256: "sha256",
+	SHA1:   "sha1",
+	MD5:    "md5",
+	CRC32:  "crc32",
+	FNV64a: "fnv64a",
+}
+
+// String returns the name of the algorithm, such as "sha256".
+func (a HashAlgorithm) String() string {
+	if a < 0 || int(a) >= len(hashNames) {
+		return "unknown"
+	}
+	return hashNames[a]
+}
+
+// parseHashAlgorithm returns the algorithm with the given name, as returned by String.
+func parseHashAlgorithm(name string) (HashAlgorithm, error) {
+	for a, hashName := range hashNames {
+		if HashAlgorithm(a) != NoHash && hashName == name {
+			return HashAlgorithm(a), nil
+		}
+	}
+	return NoHash, UnknownHashErr
+}
+
+// newHash returns a new hash computing digests with the given algorithm.
+func newHash(algorithm HashAlgorithm) (hash.Hash, error) {
+	switch algorithm {
+	case SHA256:
+		return sha256.New(), nil
+	case SHA1:
+		return sha1.New(), nil
+	case MD5:
+		return md5.New(), nil
+	case CRC32:
+		return crc32.NewIEEE(), nil
+	case FNV64a:
+		return fnv.New64a(), nil
+	}
+
+	return nil, UnknownHashErr
+}
+
+// HashFile returns the hex digest of the contents of the file
+// with the given filename computed with the given algorithm.
+func HashFile(filename string, algorithm HashAlgorithm) (string, error) {
+	h, err := newHash(algorithm)
+	if err != nil {
+		return "", err
+	}
+
+	file, err := os.Open(filename)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	if _, err := io.Copy(h, file); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Hash returns the hex digest of the file's contents computed with
+// the given algorithm. Digests are cached in Hashes and computed
+// only if not already present.
+// Hash must not be called concurrently on the same FileInfo.
+func (fi *FileInfo) Hash(algorithm HashAlgorithm) (string, error) {
+	if digest, ok := fi.Hashes[algorithm]; ok {
+		return digest, nil
+	}
+
+	digest, err := HashFile(fi.Path, algorithm)
+	if err != nil {
+		return "", err
+	}
+
+	if fi.Hashes == nil {
+		fi.Hashes = make(map[HashAlgorithm]string)
+	}
+	fi.Hashes[algorithm] = digest
+	return digest, nil
+}
+
+// hashFiles computes the digests of the given files with the given function,
+// hashing up to the given number of files concurrently.
+// If workers is not positive, the number of CPUs is used.
+func hashFiles(fileInfos []*FileInfo, workers int, hash func(fi *FileInfo) (string, error)) ([]string, []error) {
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	digests := make([]string, len(fileInfos))
+	errs := make([]error, len(fileInfos))
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				digests[i], errs[i] = hash(fileInfos[i])
+			}
+		}()
+	}
+	for i := range fileInfos {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	return digests, errs
+}
@@ -0,0 +1,222 @@
+package fs
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHashFile(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "dir")
+	assert.Nil(err)
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "file.txt")
+	assert.Nil(ioutil.WriteFile(filename, []byte("hello"), defaultFilePermissions))
+
+	type args struct {
+		filename  string
+		algorithm HashAlgorithm
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    string
+		wantErr bool
+	}{
+		{
+			"sha256",
+			args{filename, SHA256},
+			"2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824",
+			false,
+		},
+		{
+			"sha1",
+			args{filename, SHA1},
+			"aaf4c61ddcc5e8a2dabede0f3b482cd9aea9434d",
+			false,
+		},
+		{
+			"md5",
+			args{filename, MD5},
+			"5d41402abc4b2a76b9719d911017c592",
+			false,
+		},
+		{
+			"crc32",
+			args{filename, CRC32},
+			"3610a686",
+			false,
+		},
+		{
+			"fnv64a",
+			args{filename, FNV64a},
+			"a430d84680aabd0b",
+			false,
+		},
+		{
+			"no hash",
+			args{filename, NoHash},
+			"",
+			true,
+		},
+		{
+			"unknown algorithm",
+			args{filename, HashAlgorithm(100)},
+			"",
+			true,
+		},
+		{
+			"missing file",
+			args{filepath.Join(dir, "missing.txt"), SHA256},
+			"",
+			true,
+		},
+	}
+	for _, tt := range tests {
+		got, err := HashFile(tt.args.filename, tt.args.algorithm)
+		assert.Equal(tt.wantErr, err != nil, tt.name)
+		assert.Equal(tt.want, got, tt.name)
+	}
+}
+
+func TestHashAlgorithm_String(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal("sha256", SHA256.String())
+	assert.Equal("fnv64a", FNV64a.String())
+	assert.Equal("unknown", HashAlgorithm(-1).String())
+	assert.Equal("unknown", HashAlgorithm(100).String())
+}
+
+func TestFileInfo_Hash(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "dir")
+	assert.Nil(err)
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "file.txt")
+	assert.Nil(ioutil.WriteFile(filename, []byte("hello"), defaultFilePermissions))
+
+	fi, err := ReadFileInfo(filename)
+	assert.Nil(err)
+	assert.Nil(fi.Hashes)
+
+	digest, err := fi.Hash(MD5)
+	assert.Nil(err)
+	assert.Equal("5d41402abc4b2a76b9719d911017c592", digest)
+	assert.Equal(map[HashAlgorithm]string{MD5: digest}, fi.Hashes)
+
+	// Cached digests are not computed again.
+	assert.Nil(os.Remove(filename))
+	digest, err = fi.Hash(MD5)
+	assert.Nil(err)
+	assert.Equal("5d41402abc4b2a76b9719d911017c592", digest)
+
+	_, err = fi.Hash(SHA1)
+	assert.NotNil(err)
+	assert.Equal(1, len(fi.Hashes))
+}
+
+func TestReadDir_hash(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "dir")
+	assert.Nil(err)
+	defer os.RemoveAll(dir)
+	assert.Nil(os.Mkdir(filepath.Join(dir, "sub"), 0755))
+	for _, name := range []string{"1.txt", "2.txt", "3.txt"} {
+		assert.Nil(ioutil.WriteFile(filepath.Join(dir, name), []byte("hello"), defaultFilePermissions))
+	}
+
+	options := &ReadDirOptions{
+		IncludeDirs: true,
+		Hash:        CRC32,
+		HashWorkers: 2,
+	}
+	fileInfos, err := ReadDir(dir, options)
+	assert.Nil(err)
+	assert.Equal(4, len(fileInfos))
+	for _, fi := range fileInfos {
+		if fi.IsDir() {
+			assert.Nil(fi.Hashes, fi.Path)
+			continue
+		}
+		assert.Equal(map[HashAlgorithm]string{CRC32: "3610a686"}, fi.Hashes, fi.Path)
+	}
+
+	// Files whose digests cannot be computed are handled as errors
+	// and returned without digests.
+	removeFile := func(fi *FileInfo) bool {
+		if fi.Name == "2.txt" {
+			assert.Nil(os.Remove(fi.Path))
+		}
+		return true
+	}
+	options = &ReadDirOptions{
+		Hash:        SHA256,
+		Filter:      removeFile,
+		ErrorPolicy: CollectErrors,
+	}
+	fileInfos, err = ReadDir(dir, options)
+	assert.Equal([]string{"1.txt", "2.txt", "3.txt"}, relPaths(fileInfos))
+	assert.Nil(fileInfos[1].Hashes)
+	assert.IsType(MultiErr{}, err)
+	assert.Equal(1, len(err.(MultiErr)))
+	assert.Equal(filepath.Join(dir, "2.txt"), err.(MultiErr)[0].Path)
+
+	options.ErrorPolicy = AbortOnError
+	assert.Nil(ioutil.WriteFile(filepath.Join(dir, "2.txt"), []byte("hello"), defaultFilePermissions))
+	fileInfos, err = ReadDir(dir, options)
+	assert.Equal([]string{"1.txt"}, relPaths(fileInfos))
+	assert.IsType(&PathErr{}, err)
+}
+
+func TestReadDirPage_hash(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "dir")
+	assert.Nil(err)
+	defer os.RemoveAll(dir)
+	for _, name := range []string{"a", "b", "c", "d", "e"} {
+		assert.Nil(ioutil.WriteFile(filepath.Join(dir, name), []byte(name), defaultFilePermissions))
+	}
+
+	// Files that cannot be hashed do not shorten pages.
+	removeFile := func(fi *FileInfo) bool {
+		if fi.Name == "c" {
+			_ = os.Remove(fi.Path)
+		}
+		return true
+	}
+	options := &ReadDirOptions{
+		Hash:     SHA256,
+		MaxFiles: 2,
+		Filter:   removeFile,
+	}
+
+	var pages [][]string
+	cursor := ""
+	for {
+		fileInfos, next, err := ReadDirPage(dir, options, cursor)
+		assert.Nil(err)
+		pages = append(pages, relPaths(fileInfos))
+		if next == "" {
+			break
+		}
+		cursor = next
+	}
+	// The file removed while reading the first page is not read again.
+	assert.Equal([][]string{{"a", "b"}, {"d", "e"}}, pages)
+
+	assert.Nil(ioutil.WriteFile(filepath.Join(dir, "c"), []byte("c"), defaultFilePermissions))
+	options.MaxFiles = 3
+	fileInfos, err := ReadDir(dir, options)
+	assert.Nil(err)
+	assert.Equal([]string{"a", "b", "c"}, relPaths(fileInfos))
+	assert.Nil(fileInfos[2].Hashes)
+}
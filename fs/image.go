@@ -0,0 +1,71 @@
+package fs
+
+import (
+	"image"
+	"os"
+
+	// Register the supported image formats.
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+)
+
+// ImageInfo represents the metadata read from the header of an image file.
+type ImageInfo struct {
+	Width  int    // width in pixels
+	Height int    // height in pixels
+	Format string // image format: "gif", "png" or "jpeg"
+}
+
+// ReadImageInfo returns the metadata of the GIF, PNG or JPEG image file
+// with the given filename, decoding its header only.
+// If the file is not in one of the supported formats,
+// ReadImageInfo returns NotImageErr.
+func ReadImageInfo(filename string) (*ImageInfo, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	config, format, err := image.DecodeConfig(file)
+	if err == image.ErrFormat {
+		return nil, NotImageErr
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &ImageInfo{Width: config.Width, Height: config.Height, Format: format}, nil
+}
+
+// ReadImageInfo returns the image metadata of the file,
+// as described by the package-level ReadImageInfo.
+// The metadata is cached in Image and read only if not already set.
+func (fi *FileInfo) ReadImageInfo() (*ImageInfo, error) {
+	if fi.Image != nil {
+		return fi.Image, nil
+	}
+
+	info, err := ReadImageInfo(fi.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	fi.Image = info
+	return info, nil
+}
+
+// matchImageSize returns true if the given file is an image
+// at least as large as specified by the given options.
+func matchImageSize(fi *FileInfo, options *ReadDirOptions) (bool, error) {
+	info, err := fi.ReadImageInfo()
+	if err == NotImageErr {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return info.Width >= options.MinImageWidth && info.Height >= options.MinImageHeight, nil
+}
@@ -0,0 +1,192 @@
+package fs
+
+import (
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// writeTestImage writes an image with the given format and dimensions.
+func writeTestImage(filename, format string, width, height int) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	img := image.NewGray(image.Rect(0, 0, width, height))
+	switch format {
+	case "gif":
+		return gif.Encode(file, img, nil)
+	case "jpeg":
+		return jpeg.Encode(file, img, nil)
+	default:
+		return png.Encode(file, img)
+	}
+}
+
+func TestReadImageInfo(t *testing.T) {
+	assert := assert.New(t)
+
+	wd, err := os.Getwd()
+	assert.Nil(err)
+	testdir1 := filepath.Join(filepath.Dir(wd), "testdata", "read_dir_test")
+
+	dir, err := ioutil.TempDir("", "dir")
+	assert.Nil(err)
+	defer os.RemoveAll(dir)
+	assert.Nil(writeTestImage(filepath.Join(dir, "image.gif"), "gif", 30, 20))
+	assert.Nil(writeTestImage(filepath.Join(dir, "image.png"), "png", 640, 480))
+	assert.Nil(writeTestImage(filepath.Join(dir, "image.jpg"), "jpeg", 16, 9))
+	assert.Nil(writeTestImage(filepath.Join(dir, "png.gif"), "png", 2, 1))
+	assert.Nil(ioutil.WriteFile(filepath.Join(dir, "text.gif"), []byte("hello"), defaultFilePermissions))
+	assert.Nil(ioutil.WriteFile(filepath.Join(dir, "truncated.png"), []byte("\x89PNG\r\n\x1a\n"), defaultFilePermissions))
+
+	tests := []struct {
+		name     string
+		filename string
+		want     *ImageInfo
+		wantErr  error
+	}{
+		{
+			"fixture",
+			filepath.Join(testdir1, "dir1", "40.gif"),
+			&ImageInfo{Width: 1, Height: 1, Format: "gif"},
+			nil,
+		},
+		{
+			"gif",
+			filepath.Join(dir, "image.gif"),
+			&ImageInfo{Width: 30, Height: 20, Format: "gif"},
+			nil,
+		},
+		{
+			"png",
+			filepath.Join(dir, "image.png"),
+			&ImageInfo{Width: 640, Height: 480, Format: "png"},
+			nil,
+		},
+		{
+			"jpeg",
+			filepath.Join(dir, "image.jpg"),
+			&ImageInfo{Width: 16, Height: 9, Format: "jpeg"},
+			nil,
+		},
+		{
+			"misnamed",
+			filepath.Join(dir, "png.gif"),
+			&ImageInfo{Width: 2, Height: 1, Format: "png"},
+			nil,
+		},
+		{
+			"not an image",
+			filepath.Join(dir, "text.gif"),
+			nil,
+			NotImageErr,
+		},
+	}
+	for _, tt := range tests {
+		got, err := ReadImageInfo(tt.filename)
+		assert.Equal(tt.wantErr, err, tt.name)
+		assert.Equal(tt.want, got, tt.name)
+	}
+
+	_, err = ReadImageInfo(filepath.Join(dir, "truncated.png"))
+	assert.NotNil(err)
+	assert.NotEqual(NotImageErr, err)
+
+	_, err = ReadImageInfo(filepath.Join(dir, "missing.png"))
+	assert.True(os.IsNotExist(err))
+}
+
+func TestFileInfo_ReadImageInfo(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "dir")
+	assert.Nil(err)
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "image.png")
+	assert.Nil(writeTestImage(filename, "png", 3, 4))
+
+	fi, err := ReadFileInfo(filename)
+	assert.Nil(err)
+	assert.Nil(fi.Image)
+
+	got, err := fi.ReadImageInfo()
+	assert.Nil(err)
+	want := &ImageInfo{Width: 3, Height: 4, Format: "png"}
+	assert.Equal(want, got)
+	assert.Equal(want, fi.Image)
+
+	// Cached metadata is not read again.
+	assert.Nil(os.Remove(filename))
+	got, err = fi.ReadImageInfo()
+	assert.Nil(err)
+	assert.Equal(want, got)
+}
+
+func TestReadDir_imageSize(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "dir")
+	assert.Nil(err)
+	defer os.RemoveAll(dir)
+	assert.Nil(os.Mkdir(filepath.Join(dir, "sub"), 0755))
+	assert.Nil(writeTestImage(filepath.Join(dir, "pixel.gif"), "gif", 1, 1))
+	assert.Nil(writeTestImage(filepath.Join(dir, "thumb.jpg"), "jpeg", 100, 75))
+	assert.Nil(writeTestImage(filepath.Join(dir, "photo.png"), "png", 800, 600))
+	assert.Nil(writeTestImage(filepath.Join(dir, "banner.png"), "png", 800, 50))
+	assert.Nil(ioutil.WriteFile(filepath.Join(dir, "text.gif"), []byte("hello"), defaultFilePermissions))
+
+	tests := []struct {
+		name    string
+		options *ReadDirOptions
+		want    []string
+	}{
+		{
+			"no image filters",
+			&ReadDirOptions{},
+			[]string{"banner.png", "photo.png", "pixel.gif", "text.gif", "thumb.jpg"},
+		},
+		{
+			"min width",
+			&ReadDirOptions{
+				MinImageWidth: 100,
+			},
+			[]string{"banner.png", "photo.png", "thumb.jpg"},
+		},
+		{
+			"min width and height",
+			&ReadDirOptions{
+				MinImageWidth:  100,
+				MinImageHeight: 75,
+			},
+			[]string{"photo.png", "thumb.jpg"},
+		},
+		{
+			"any image, include dirs",
+			&ReadDirOptions{
+				IncludeDirs:   true,
+				MinImageWidth: 1,
+			},
+			[]string{"banner.png", "photo.png", "pixel.gif", "sub", "thumb.jpg"},
+		},
+	}
+	for _, tt := range tests {
+		got, err := ReadDir(dir, tt.options)
+		assert.Nil(err, tt.name)
+		assert.Equal(tt.want, relPaths(got), tt.name)
+		for _, fi := range got {
+			if tt.options.MinImageWidth > 0 && fi.IsRegular() {
+				assert.NotNil(fi.Image, tt.name)
+			}
+		}
+	}
+}
@@ -0,0 +1,509 @@
+package fs
+
+import (
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// fileRecord represents a file in JSON, JSON Lines and CSV listings,
+// whose field names and CSV columns are given by the json tags.
+//
+// Paths that are not valid UTF-8 are written with invalid bytes
+// replaced by U+FFFD, together with their original bytes
+// in the corresponding base64 field, which takes precedence when reading.
+// Name, Stem, Ext and Hidden are derived from the path and ignored when reading.
+// Image and EXIF metadata are not included.
+type fileRecord struct {
+	Path           string            `json:"path"`
+	PathBase64     []byte            `json:"path_base64,omitempty"`
+	Root           string            `json:"root,omitempty"`
+	RootBase64     []byte            `json:"root_base64,omitempty"`
+	RelPath        string            `json:"rel_path,omitempty"`
+	RelPathBase64  []byte            `json:"rel_path_base64,omitempty"`
+	RealPath       string            `json:"real_path,omitempty"`
+	RealPathBase64 []byte            `json:"real_path_base64,omitempty"`
+	Name           string            `json:"name"`
+	Stem           string            `json:"stem,omitempty"`
+	Ext            string            `json:"ext,omitempty"`
+	Type           string            `json:"type"`
+	Mode           string            `json:"mode,omitempty"`
+	Size           int64             `json:"size"`
+	ModTime        string            `json:"mod_time,omitempty"`
+	AccessTime     string            `json:"access_time,omitempty"`
+	ChangeTime     string            `json:"change_time,omitempty"`
+	Depth          int               `json:"depth,omitempty"`
+	Hidden         bool              `json:"hidden,omitempty"`
+	UID            uint32            `json:"uid,omitempty"`
+	GID            uint32            `json:"gid,omitempty"`
+	Inode          uint64            `json:"inode,omitempty"`
+	Dev            uint64            `json:"dev,omitempty"`
+	Nlink          uint64            `json:"nlink,omitempty"`
+	Blocks         int64             `json:"blocks,omitempty"`
+	ContentType    string            `json:"content_type,omitempty"`
+	Hashes         map[string]string `json:"hashes,omitempty"`
+}
+
+// fileTypeNames holds the names of the file types in listings.
+var fileTypeNames = []struct {
+	typ  os.FileMode
+	name string
+}{
+	{0, "file"},
+	{os.ModeDir, "dir"},
+	{os.ModeSymlink, "symlink"},
+	{os.ModeNamedPipe, "named_pipe"},
+	{os.ModeSocket, "socket"},
+	{os.ModeDevice | os.ModeCharDevice, "char_device"},
+	{os.ModeDevice, "device"},
+	{os.ModeIrregular, "irregular"},
+}
+
+// WriteJSON writes the given files to the given writer as a JSON array.
+// Listings written by WriteJSON can be read with ReadJSON.
+func WriteJSON(w io.Writer, fileInfos []*FileInfo) error {
+	records := make([]*fileRecord, len(fileInfos))
+	for i, fi := range fileInfos {
+		records[i] = newFileRecord(fi)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	return enc.Encode(records)
+}
+
+// ReadJSON reads from the given reader the files of a JSON array
+// written by WriteJSON.
+func ReadJSON(r io.Reader) ([]*FileInfo, error) {
+	var records []*fileRecord
+	if err := json.NewDecoder(r).Decode(&records); err != nil {
+		return nil, err
+	}
+
+	fileInfos := make([]*FileInfo, len(records))
+	for i, rec := range records {
+		fi, err := rec.fileInfo()
+		if err != nil {
+			return nil, fmt.Errorf("fs: invalid record %d: %v", i+1, err)
+		}
+		fileInfos[i] = fi
+	}
+	return fileInfos, nil
+}
+
+// WriteJSONLines writes the given files to the given writer
+// as JSON Lines, with one JSON object per line.
+// Listings written by WriteJSONLines can be read with ReadJSONLines.
+func WriteJSONLines(w io.Writer, fileInfos []*FileInfo) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	for _, fi := range fileInfos {
+		if err := enc.Encode(newFileRecord(fi)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReadJSONLines reads from the given reader the files
+// written as JSON Lines by WriteJSONLines.
+func ReadJSONLines(r io.Reader) ([]*FileInfo, error) {
+	dec := json.NewDecoder(r)
+	var fileInfos []*FileInfo
+	for {
+		var rec fileRecord
+		err := dec.Decode(&rec)
+		if err == io.EOF {
+			return fileInfos, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("fs: invalid record %d: %v", len(fileInfos)+1, err)
+		}
+
+		fi, err := rec.fileInfo()
+		if err != nil {
+			return nil, fmt.Errorf("fs: invalid record %d: %v", len(fileInfos)+1, err)
+		}
+		fileInfos = append(fileInfos, fi)
+	}
+}
+
+// WriteCSV writes the given files to the given writer as CSV,
+// with a header row holding the names of the columns.
+// Listings written by WriteCSV can be read with ReadCSV.
+func WriteCSV(w io.Writer, fileInfos []*FileInfo) error {
+	columns := recordColumns()
+	cw := csv.NewWriter(w)
+	if err := cw.Write(columns); err != nil {
+		return err
+	}
+
+	row := make([]string, len(columns))
+	for _, fi := range fileInfos {
+		v := reflect.ValueOf(newFileRecord(fi)).Elem()
+		for i := range columns {
+			row[i] = formatRecordField(v.Field(i))
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// ReadCSV reads from the given reader the files written as CSV by WriteCSV.
+// Columns are identified by the header row and can be in any order;
+// the path column is required, while unknown columns are ignored.
+func ReadCSV(r io.Reader) ([]*FileInfo, error) {
+	cr := csv.NewReader(r)
+	header, err := cr.Read()
+	if err != nil {
+		return nil, err
+	}
+
+	fields := make(map[string]int)
+	for i, column := range recordColumns() {
+		fields[column] = i
+	}
+	hasPath := false
+	for _, column := range header {
+		hasPath = hasPath || column == "path"
+	}
+	if !hasPath {
+		return nil, fmt.Errorf("fs: missing path column")
+	}
+
+	var fileInfos []*FileInfo
+	for {
+		row, err := cr.Read()
+		if err == io.EOF {
+			return fileInfos, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var rec fileRecord
+		v := reflect.ValueOf(&rec).Elem()
+		for i, column := range header {
+			field, ok := fields[column]
+			if !ok || row[i] == "" {
+				continue
+			}
+			if err := parseRecordField(v.Field(field), row[i]); err != nil {
+				return nil, fmt.Errorf("fs: invalid record %d: %s: %v", len(fileInfos)+1, column, err)
+			}
+		}
+
+		fi, err := rec.fileInfo()
+		if err != nil {
+			return nil, fmt.Errorf("fs: invalid record %d: %v", len(fileInfos)+1, err)
+		}
+		fileInfos = append(fileInfos, fi)
+	}
+}
+
+func newFileRecord(fi *FileInfo) *fileRecord {
+	rec := &fileRecord{
+		Name:        validUTF8(fi.Name),
+		Stem:        validUTF8(fi.Stem),
+		Ext:         validUTF8(fi.Ext),
+		Type:        fileTypeName(fi.Type),
+		Size:        fi.Size,
+		ModTime:     formatRecordTime(fi.ModTime),
+		AccessTime:  formatRecordTime(fi.AccessTime),
+		ChangeTime:  formatRecordTime(fi.ChangeTime),
+		Depth:       fi.Depth,
+		Hidden:      fi.Hidden,
+		UID:         fi.UID,
+		GID:         fi.GID,
+		Inode:       fi.Inode,
+		Dev:         fi.Dev,
+		Nlink:       fi.Nlink,
+		Blocks:      fi.Blocks,
+		ContentType: fi.ContentType,
+	}
+	rec.Path, rec.PathBase64 = encodeRecordPath(fi.Path)
+	rec.Root, rec.RootBase64 = encodeRecordPath(fi.Root)
+	rec.RelPath, rec.RelPathBase64 = encodeRecordPath(fi.RelPath)
+	rec.RealPath, rec.RealPathBase64 = encodeRecordPath(fi.RealPath)
+
+	if fi.Mode != 0 {
+		rec.Mode = fi.Mode.String()
+	}
+	if len(fi.Hashes) > 0 {
+		rec.Hashes = make(map[string]string, len(fi.Hashes))
+		for algorithm, digest := range fi.Hashes {
+			rec.Hashes[algorithm.String()] = digest
+		}
+	}
+
+	return rec
+}
+
+// fileInfo returns the file represented by the record.
+func (rec *fileRecord) fileInfo() (*FileInfo, error) {
+	path := decodeRecordPath(rec.Path, rec.PathBase64)
+	if path == "" {
+		return nil, fmt.Errorf("missing path")
+	}
+
+	typ, ok := parseFileTypeName(rec.Type)
+	if !ok {
+		return nil, fmt.Errorf("unknown type %q", rec.Type)
+	}
+
+	fi := newPathInfo(path, typ)
+	fi.Root = decodeRecordPath(rec.Root, rec.RootBase64)
+	fi.RelPath = decodeRecordPath(rec.RelPath, rec.RelPathBase64)
+	fi.RealPath = decodeRecordPath(rec.RealPath, rec.RealPathBase64)
+	fi.Size = rec.Size
+	fi.Depth = rec.Depth
+	fi.UID = rec.UID
+	fi.GID = rec.GID
+	fi.Inode = rec.Inode
+	fi.Dev = rec.Dev
+	fi.Nlink = rec.Nlink
+	fi.Blocks = rec.Blocks
+	fi.ContentType = rec.ContentType
+
+	var err error
+	if rec.Mode != "" {
+		if fi.Mode, err = parseFileMode(rec.Mode); err != nil {
+			return nil, err
+		}
+	}
+	if fi.ModTime, err = parseRecordTime(rec.ModTime); err != nil {
+		return nil, err
+	}
+	if fi.AccessTime, err = parseRecordTime(rec.AccessTime); err != nil {
+		return nil, err
+	}
+	if fi.ChangeTime, err = parseRecordTime(rec.ChangeTime); err != nil {
+		return nil, err
+	}
+
+	if len(rec.Hashes) > 0 {
+		fi.Hashes = make(map[HashAlgorithm]string, len(rec.Hashes))
+		for name, digest := range rec.Hashes {
+			algorithm, err := parseHashAlgorithm(name)
+			if err != nil {
+				return nil, fmt.Errorf("unknown hash algorithm %q", name)
+			}
+			fi.Hashes[algorithm] = digest
+		}
+	}
+
+	return fi, nil
+}
+
+// recordColumns returns the names of the CSV columns.
+func recordColumns() []string {
+	t := reflect.TypeOf(fileRecord{})
+	columns := make([]string, t.NumField())
+	for i := range columns {
+		columns[i] = strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+	}
+	return columns
+}
+
+// formatRecordField returns the CSV value of the given record field.
+// Zero values are written as empty values.
+func formatRecordField(v reflect.Value) string {
+	switch v.Kind() {
+	case reflect.String:
+		return v.String()
+	case reflect.Int, reflect.Int64:
+		if v.Int() != 0 {
+			return strconv.FormatInt(v.Int(), 10)
+		}
+	case reflect.Uint32, reflect.Uint64:
+		if v.Uint() != 0 {
+			return strconv.FormatUint(v.Uint(), 10)
+		}
+	case reflect.Bool:
+		if v.Bool() {
+			return "true"
+		}
+	case reflect.Slice:
+		if v.Len() > 0 {
+			return base64.StdEncoding.EncodeToString(v.Bytes())
+		}
+	case reflect.Map:
+		// Hashes are written as space-separated "algorithm:digest" pairs.
+		var pairs []string
+		for _, key := range v.MapKeys() {
+			pairs = append(pairs, key.String()+":"+v.MapIndex(key).String())
+		}
+		sort.Strings(pairs)
+		return strings.Join(pairs, " ")
+	}
+	return ""
+}
+
+// parseRecordField sets the given record field from the given CSV value.
+func parseRecordField(v reflect.Value, value string) error {
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(value)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(value, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Slice:
+		data, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			return err
+		}
+		v.SetBytes(data)
+	case reflect.Map:
+		hashes := make(map[string]string)
+		for _, pair := range strings.Fields(value) {
+			i := strings.Index(pair, ":")
+			if i < 0 {
+				return fmt.Errorf("invalid hash %q", pair)
+			}
+			hashes[pair[:i]] = pair[i+1:]
+		}
+		v.Set(reflect.ValueOf(hashes))
+	}
+	return nil
+}
+
+// encodeRecordPath returns the given path as valid UTF-8
+// and, if the path is not valid UTF-8, its original bytes.
+func encodeRecordPath(path string) (string, []byte) {
+	if utf8.ValidString(path) {
+		return path, nil
+	}
+	return validUTF8(path), []byte(path)
+}
+
+// decodeRecordPath returns the original bytes of a path, if present,
+// or the path itself.
+func decodeRecordPath(path string, original []byte) string {
+	if len(original) > 0 {
+		return string(original)
+	}
+	return path
+}
+
+// validUTF8 returns the given string with each sequence
+// of invalid UTF-8 bytes replaced by U+FFFD.
+func validUTF8(s string) string {
+	if utf8.ValidString(s) {
+		return s
+	}
+
+	var b strings.Builder
+	invalid := false
+	for _, r := range s {
+		if r == utf8.RuneError {
+			if !invalid {
+				b.WriteRune(utf8.RuneError)
+			}
+			invalid = true
+			continue
+		}
+		b.WriteRune(r)
+		invalid = false
+	}
+	return b.String()
+}
+
+func formatRecordTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339Nano)
+}
+
+func parseRecordTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339Nano, value)
+}
+
+// fileTypeName returns the name of the given file type in listings.
+func fileTypeName(typ os.FileMode) string {
+	for _, t := range fileTypeNames {
+		if t.typ == typ {
+			return t.name
+		}
+	}
+	return "irregular"
+}
+
+func parseFileTypeName(name string) (os.FileMode, bool) {
+	if name == "" {
+		return 0, true
+	}
+	for _, t := range fileTypeNames {
+		if t.name == name {
+			return t.typ, true
+		}
+	}
+	return 0, false
+}
+
+// fileModeChars holds the characters representing
+// the file mode bits in os.FileMode.String, from the highest bit.
+const fileModeChars = "dalTLDpSugct?"
+
+// parseFileMode parses a file mode formatted by os.FileMode.String.
+func parseFileMode(s string) (os.FileMode, error) {
+	const rwx = "rwxrwxrwx"
+	if len(s) < len(rwx)+1 {
+		return 0, fmt.Errorf("invalid mode %q", s)
+	}
+
+	var mode os.FileMode
+	prefix, perm := s[:len(s)-len(rwx)], s[len(s)-len(rwx):]
+	if prefix != "-" {
+		for _, c := range prefix {
+			i := strings.IndexRune(fileModeChars, c)
+			if i < 0 {
+				return 0, fmt.Errorf("invalid mode %q", s)
+			}
+			mode |= 1 << uint(32-1-i)
+		}
+	}
+
+	for i, c := range perm {
+		switch c {
+		case rune(rwx[i]):
+			mode |= 1 << uint(len(rwx)-1-i)
+		case '-':
+		default:
+			return 0, fmt.Errorf("invalid mode %q", s)
+		}
+	}
+
+	return mode, nil
+}
@@ -0,0 +1,274 @@
+package fs
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// listingTestFiles returns files read from a test directory
+// together with files with paths that are not valid UTF-8
+// or that are not valid on all systems.
+func listingTestFiles(t *testing.T, dir string) []*FileInfo {
+	assert := assert.New(t)
+
+	modTime := time.Date(2019, 7, 14, 10, 30, 15, 123456789, time.UTC)
+	assert.Nil(os.Mkdir(filepath.Join(dir, "sub"), 0750))
+	assert.Nil(writeTestFile(filepath.Join(dir, "a, b.txt"), 10, modTime))
+	assert.Nil(writeTestFile(filepath.Join(dir, "sub", ".hidden"), 20, modTime))
+	assert.Nil(writeTestFile(filepath.Join(dir, "sub", "new line.gif"), 30, modTime))
+
+	fileInfos, err := ReadDir(dir, &ReadDirOptions{
+		IncludeSubdirs: true,
+		IncludeDirs:    true,
+		Hash:           MD5,
+		ContentTypes:   []string{"application/octet-stream"},
+	})
+	assert.Nil(err)
+	assert.Equal(4, len(fileInfos))
+
+	invalid := newPathInfo(filepath.Join(dir, "sub", "invalid\xff\xfe.gif"), 0)
+	invalid.Root = dir
+	invalid.RelPath = "sub/invalid\xff\xfe.gif"
+	invalid.RealPath = filepath.Join(dir, "real\xff", "invalid\xff\xfe.gif")
+	invalid.Size = 40
+	invalid.Mode = 0644
+	invalid.ModTime = modTime
+	invalid.Depth = 2
+	invalid.Hashes = map[HashAlgorithm]string{SHA256: "abcd", CRC32: "ef01"}
+	quoted := newPathInfo(filepath.Join(dir, "sub", "\"quoted\"\nname.gif"), 0)
+	quoted.Root = dir
+	quoted.RelPath = "sub/\"quoted\"\nname.gif"
+	quoted.Size = 50
+	quoted.Mode = 0644
+	quoted.ModTime = modTime
+	quoted.Depth = 2
+	pipe := newPathInfo(filepath.Join(dir, "pipe"), os.ModeNamedPipe)
+	pipe.Root = dir
+	pipe.RelPath = "pipe"
+	pipe.Depth = 1
+
+	return append(fileInfos, invalid, quoted, pipe)
+}
+
+// assertSameFileInfos asserts that the given lists of files are equal,
+// comparing times by instant.
+func assertSameFileInfos(t *testing.T, want, got []*FileInfo, msg string) {
+	assert := assert.New(t)
+
+	if !assert.Equal(len(want), len(got), msg) {
+		return
+	}
+	for i := range want {
+		w, g := *want[i], *got[i]
+		assert.True(w.ModTime.Equal(g.ModTime), msg)
+		assert.True(w.AccessTime.Equal(g.AccessTime), msg)
+		assert.True(w.ChangeTime.Equal(g.ChangeTime), msg)
+		g.ModTime, g.AccessTime, g.ChangeTime = w.ModTime, w.AccessTime, w.ChangeTime
+		assert.Equal(w, g, msg)
+	}
+}
+
+func TestListing_roundTrip(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "dir")
+	assert.Nil(err)
+	defer os.RemoveAll(dir)
+	fileInfos := listingTestFiles(t, dir)
+
+	tests := []struct {
+		name  string
+		write func(w io.Writer, fileInfos []*FileInfo) error
+		read  func(r io.Reader) ([]*FileInfo, error)
+	}{
+		{"json", WriteJSON, ReadJSON},
+		{"json lines", WriteJSONLines, ReadJSONLines},
+		{"csv", WriteCSV, ReadCSV},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		assert.Nil(tt.write(&buf, fileInfos), tt.name)
+		assert.True(strings.Contains(buf.String(), "invalid�.gif"), tt.name)
+
+		got, err := tt.read(&buf)
+		assert.Nil(err, tt.name)
+		assertSameFileInfos(t, fileInfos, got, tt.name)
+
+		// Empty listings.
+		buf.Reset()
+		assert.Nil(tt.write(&buf, nil), tt.name)
+		got, err = tt.read(&buf)
+		assert.Nil(err, tt.name)
+		assert.Empty(got, tt.name)
+	}
+}
+
+func TestWriteJSONLines(t *testing.T) {
+	assert := assert.New(t)
+
+	fi := newPathInfo(filepath.FromSlash("/dir/file.gif"), 0)
+	fi.Size = 10
+	fi.Mode = 0644
+	fi.ModTime = time.Date(2019, 7, 14, 10, 30, 15, 0, time.UTC)
+	dir := newPathInfo(filepath.FromSlash("/dir/sub"), os.ModeDir)
+
+	var buf bytes.Buffer
+	assert.Nil(WriteJSONLines(&buf, []*FileInfo{fi, dir}))
+	path, err := filepathJSON(fi.Path)
+	assert.Nil(err)
+	dirPath, err := filepathJSON(dir.Path)
+	assert.Nil(err)
+	want := `{"path":` + path + `,"name":"file.gif","stem":"file","ext":".gif","type":"file","mode":"-rw-r--r--","size":10,"mod_time":"2019-07-14T10:30:15Z"}` + "\n" +
+		`{"path":` + dirPath + `,"name":"sub","stem":"sub","type":"dir","size":0}` + "\n"
+	assert.Equal(want, buf.String())
+}
+
+// filepathJSON returns the given path as a JSON string.
+func filepathJSON(path string) (string, error) {
+	data, err := json.Marshal(path)
+	return string(data), err
+}
+
+func TestReadCSV(t *testing.T) {
+	assert := assert.New(t)
+
+	path := filepath.FromSlash("/dir/file.gif")
+	tests := []struct {
+		name    string
+		data    string
+		want    []*FileInfo
+		wantErr bool
+	}{
+		{
+			"reordered and unknown columns",
+			"size,extra,path,type\n10,x," + path + ",file\n",
+			func() []*FileInfo {
+				fi := newPathInfo(path, 0)
+				fi.Size = 10
+				return []*FileInfo{fi}
+			}(),
+			false,
+		},
+		{
+			"no rows",
+			"path\n",
+			nil,
+			false,
+		},
+		{
+			"empty",
+			"",
+			nil,
+			true,
+		},
+		{
+			"missing path column",
+			"name,size\nfile.gif,10\n",
+			nil,
+			true,
+		},
+		{
+			"missing path",
+			"path,size\n,10\n",
+			nil,
+			true,
+		},
+		{
+			"invalid size",
+			"path,size\n" + path + ",ten\n",
+			nil,
+			true,
+		},
+		{
+			"invalid type",
+			"path,type\n" + path + ",unknown\n",
+			nil,
+			true,
+		},
+		{
+			"invalid mode",
+			"path,mode\n" + path + ",rw-r--r--\n",
+			nil,
+			true,
+		},
+		{
+			"invalid time",
+			"path,mod_time\n" + path + ",yesterday\n",
+			nil,
+			true,
+		},
+		{
+			"invalid hash algorithm",
+			"path,hashes\n" + path + ",sha3:abcd\n",
+			nil,
+			true,
+		},
+		{
+			"invalid base64 path",
+			"path,path_base64\n" + path + ",***\n",
+			nil,
+			true,
+		},
+	}
+	for _, tt := range tests {
+		got, err := ReadCSV(strings.NewReader(tt.data))
+		assert.Equal(tt.wantErr, err != nil, tt.name)
+		assert.Equal(tt.want, got, tt.name)
+	}
+}
+
+func TestReadJSON_errors(t *testing.T) {
+	assert := assert.New(t)
+
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"empty", ""},
+		{"not an array", `{"path":"/file"}`},
+		{"missing path", `[{"size":10}]`},
+		{"invalid type", `[{"path":"/file","type":"unknown"}]`},
+	}
+	for _, tt := range tests {
+		_, err := ReadJSON(strings.NewReader(tt.data))
+		assert.NotNil(err, tt.name)
+
+		if strings.HasPrefix(tt.data, "[") {
+			_, err = ReadJSONLines(strings.NewReader(tt.data[1 : len(tt.data)-1]))
+			assert.NotNil(err, tt.name)
+		}
+	}
+}
+
+func Test_parseFileMode(t *testing.T) {
+	assert := assert.New(t)
+
+	modes := []os.FileMode{
+		0,
+		0644,
+		os.ModeDir | 0755,
+		os.ModeSymlink | 0777,
+		os.ModeSetuid | os.ModeSetgid | os.ModeSticky | 0700,
+		os.ModeDevice | os.ModeCharDevice | 0600,
+		os.ModeNamedPipe | os.ModeSocket | os.ModeIrregular,
+	}
+	for _, mode := range modes {
+		got, err := parseFileMode(mode.String())
+		assert.Nil(err, mode.String())
+		assert.Equal(mode, got, mode.String())
+	}
+
+	for _, s := range []string{"", "-rw-r--r-", "xrw-r--r--", "-rw-r--r-x-", "-rwxrwxrwz"} {
+		_, err := parseFileMode(s)
+		assert.NotNil(err, s)
+	}
+}
@@ -0,0 +1,369 @@
+package fs
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// ManifestFormat represents the format of checksum manifests.
+type ManifestFormat int
+
+const (
+	// GNUManifest formats manifests as written by sha256sum and md5sum,
+	// with lines such as "<digest>  <path>".
+	GNUManifest ManifestFormat = iota
+
+	// BSDManifest formats manifests as written by BSD tools
+	// and by sha256sum --tag, with lines such as "SHA256 (<path>) = <digest>".
+	BSDManifest
+)
+
+// Manifest represents the digests of the files of a directory tree.
+type Manifest struct {
+	Algorithm HashAlgorithm    // algorithm computing the digests
+	Entries   []*ManifestEntry // files in the manifest
+}
+
+// ManifestEntry represents a file in a manifest.
+type ManifestEntry struct {
+	RelPath string    // slash-separated path relative to the manifest directory
+	Size    int64     // file size in bytes, or -1 if unknown
+	ModTime time.Time // modification time, or the zero time if unknown
+	Digest  string    // hex digest of the file contents
+}
+
+// ManifestReport represents the result of the verification of a manifest.
+type ManifestReport struct {
+	Verified  []string // files with the expected contents
+	Missing   []string // files in the manifest not found in the directory
+	Extra     []string // files in the directory not in the manifest
+	Corrupted []string // files whose contents differ from the manifest
+}
+
+// OK returns true if all files in the manifest are verified
+// and no other files are found.
+func (r *ManifestReport) OK() bool {
+	return len(r.Missing) == 0 && len(r.Extra) == 0 && len(r.Corrupted) == 0
+}
+
+// CreateManifest reads the directory named by the given dirname
+// following the given options and returns a manifest of the regular files
+// found, sorted by path, with digests computed with the given algorithm.
+// Sort, MaxFiles and Hash options are ignored.
+// Errors are handled as specified by the options and are returned
+// together with the manifest of the files read.
+func CreateManifest(dirname string, algorithm HashAlgorithm, options *ReadDirOptions) (*Manifest, error) {
+	if options == nil {
+		return nil, NoReadDirOptionsErr
+	}
+	if _, err := newHash(algorithm); err != nil {
+		return nil, err
+	}
+
+	readOptions := pathOrderOptions(options)
+	readOptions.Hash = algorithm
+	fileInfos, err := ReadDir(dirname, readOptions)
+	if fileInfos == nil && err != nil {
+		return nil, err
+	}
+
+	manifest := &Manifest{Algorithm: algorithm}
+	for _, fi := range fileInfos {
+		// Files that cannot be hashed are returned without digests.
+		digest, ok := fi.Hashes[algorithm]
+		if !fi.IsRegular() || !ok {
+			continue
+		}
+
+		entry := &ManifestEntry{
+			RelPath: fi.RelPath,
+			Size:    fi.Size,
+			ModTime: fi.ModTime,
+			Digest:  digest,
+		}
+		if options.SkipStat {
+			entry.Size = -1
+		}
+		manifest.Entries = append(manifest.Entries, entry)
+	}
+
+	return manifest, err
+}
+
+// Write writes the manifest to the given writer in the given format.
+// Paths containing backslashes or newlines are escaped as by sha256sum.
+func (m *Manifest) Write(w io.Writer, format ManifestFormat) error {
+	bw := bufio.NewWriter(w)
+	tag := strings.ToUpper(m.Algorithm.String())
+	for _, entry := range m.Entries {
+		path, escaped := escapeManifestPath(entry.RelPath)
+		if escaped {
+			bw.WriteByte('\\')
+		}
+
+		if format == BSDManifest {
+			fmt.Fprintf(bw, "%s (%s) = %s\n", tag, path, entry.Digest)
+		} else {
+			fmt.Fprintf(bw, "%s  %s\n", entry.Digest, path)
+		}
+	}
+	return bw.Flush()
+}
+
+// ReadManifest reads a manifest in any of the supported formats
+// from the given reader. The algorithm is given by the tags of BSD lines
+// or, for GNU lines, by the length of the digests.
+// Empty lines and lines starting with # are ignored,
+// while paths listed more than once are invalid.
+// Sizes and modification times are not recorded in manifests
+// and are read as unknown.
+func ReadManifest(r io.Reader) (*Manifest, error) {
+	manifest := &Manifest{}
+	paths := make(map[string]bool)
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		entry, algorithm, ok := parseManifestLine(line)
+		if !ok {
+			return nil, fmt.Errorf("fs: invalid manifest line %d", n)
+		}
+		if manifest.Algorithm == NoHash {
+			manifest.Algorithm = algorithm
+		}
+		if algorithm != manifest.Algorithm {
+			return nil, fmt.Errorf("fs: manifest line %d: mixed hash algorithms", n)
+		}
+		if paths[entry.RelPath] {
+			return nil, fmt.Errorf("fs: manifest line %d: duplicate path %q", n, entry.RelPath)
+		}
+		paths[entry.RelPath] = true
+
+		manifest.Entries = append(manifest.Entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return manifest, nil
+}
+
+// parseManifestLine parses a line of a GNU or BSD manifest.
+func parseManifestLine(line string) (*ManifestEntry, HashAlgorithm, bool) {
+	escaped := strings.HasPrefix(line, "\\")
+	if escaped {
+		line = line[1:]
+	}
+
+	var path, digest string
+	var algorithm HashAlgorithm
+	if tag, ok := bsdManifestTag(line); ok {
+		// BSD line: TAG (path) = digest
+		j := strings.LastIndex(line, ") = ")
+		if j < len(tag)+2 {
+			return nil, NoHash, false
+		}
+		algorithm, _ = parseHashAlgorithm(strings.ToLower(tag))
+		path, digest = line[len(tag)+2:j], line[j+4:]
+	} else {
+		// GNU line: digest, space, text or binary mode marker, path
+		i := strings.Index(line, " ")
+		if i < 0 || i+2 > len(line) || (line[i+1] != ' ' && line[i+1] != '*') {
+			return nil, NoHash, false
+		}
+		digest, path = line[:i], line[i+2:]
+		algorithm = hashAlgorithmOfDigest(digest)
+	}
+
+	if escaped {
+		var ok bool
+		if path, ok = unescapeManifestPath(path); !ok {
+			return nil, NoHash, false
+		}
+	}
+	if path == "" || algorithm == NoHash || !isHex(digest) || len(digest) != digestLen(algorithm) {
+		return nil, NoHash, false
+	}
+
+	entry := &ManifestEntry{
+		RelPath: strings.TrimPrefix(path, "./"),
+		Size:    -1,
+		Digest:  strings.ToLower(digest),
+	}
+	return entry, algorithm, true
+}
+
+// bsdManifestTag returns the algorithm tag of the given line
+// and true if the line is a BSD manifest line.
+func bsdManifestTag(line string) (string, bool) {
+	i := strings.Index(line, " (")
+	if i < 0 {
+		return "", false
+	}
+
+	tag := line[:i]
+	if _, err := parseHashAlgorithm(strings.ToLower(tag)); err != nil {
+		return "", false
+	}
+	return tag, true
+}
+
+// hashAlgorithmOfDigest returns the algorithm computing digests
+// of the length of the given digest.
+func hashAlgorithmOfDigest(digest string) HashAlgorithm {
+	for _, algorithm := range []HashAlgorithm{SHA256, SHA1, MD5, CRC32, FNV64a} {
+		if len(digest) == digestLen(algorithm) {
+			return algorithm
+		}
+	}
+	return NoHash
+}
+
+// digestLen returns the length of the hex digests computed with the given algorithm.
+func digestLen(algorithm HashAlgorithm) int {
+	h, err := newHash(algorithm)
+	if err != nil {
+		return 0
+	}
+	return 2 * h.Size()
+}
+
+// isHex returns true if the given string holds only hex digits.
+func isHex(s string) bool {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !isDigit(c) && !('a' <= c && c <= 'f') && !('A' <= c && c <= 'F') {
+			return false
+		}
+	}
+	return true
+}
+
+// escapeManifestPath escapes backslashes and newlines in the given path
+// and returns true if the path is escaped.
+func escapeManifestPath(path string) (string, bool) {
+	if !strings.ContainsAny(path, "\\\n\r") {
+		return path, false
+	}
+
+	r := strings.NewReplacer("\\", "\\\\", "\n", "\\n", "\r", "\\r")
+	return r.Replace(path), true
+}
+
+// unescapeManifestPath reverses escapeManifestPath.
+func unescapeManifestPath(path string) (string, bool) {
+	var b strings.Builder
+	for i := 0; i < len(path); i++ {
+		c := path[i]
+		if c != '\\' {
+			b.WriteByte(c)
+			continue
+		}
+
+		i++
+		if i == len(path) {
+			return "", false
+		}
+		switch path[i] {
+		case '\\':
+			b.WriteByte('\\')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		default:
+			return "", false
+		}
+	}
+	return b.String(), true
+}
+
+// VerifyManifest reads the directory named by the given dirname following
+// the given options and verifies its regular files against the given manifest.
+// Files of known size are compared by size before being hashed.
+// Paths listed more than once are verified against their first entry.
+// Sort, MaxFiles and Hash options are ignored.
+// The options should select the same files used to create the manifest,
+// since files excluded by the options are reported as missing.
+// Errors are handled as specified by the options and are returned
+// together with the report of the files verified.
+func VerifyManifest(dirname string, manifest *Manifest, options *ReadDirOptions) (*ManifestReport, error) {
+	if options == nil {
+		return nil, NoReadDirOptionsErr
+	}
+	if _, err := newHash(manifest.Algorithm); err != nil {
+		return nil, err
+	}
+
+	readOptions := pathOrderOptions(options)
+	readOptions.SkipStat = false
+	fileInfos, err := ReadDir(dirname, readOptions)
+	if fileInfos == nil && err != nil {
+		return nil, err
+	}
+
+	handler := errorHandler{options: readOptions}
+	handler.add(err)
+
+	files := make(map[string]*FileInfo, len(fileInfos))
+	for _, fi := range fileInfos {
+		if fi.IsRegular() {
+			files[fi.RelPath] = fi
+		}
+	}
+
+	report := &ManifestReport{}
+	var toHash []*FileInfo
+	var toHashEntries []*ManifestEntry
+	listed := make(map[string]bool, len(manifest.Entries))
+	for _, entry := range manifest.Entries {
+		// Files listed more than once are verified once, against the first entry.
+		if listed[entry.RelPath] {
+			continue
+		}
+		listed[entry.RelPath] = true
+
+		fi, ok := files[entry.RelPath]
+		switch {
+		case !ok:
+			report.Missing = append(report.Missing, entry.RelPath)
+		case entry.Size >= 0 && entry.Size != fi.Size:
+			report.Corrupted = append(report.Corrupted, entry.RelPath)
+		default:
+			toHash = append(toHash, fi)
+			toHashEntries = append(toHashEntries, entry)
+		}
+	}
+
+	for _, fi := range fileInfos {
+		if fi.IsRegular() && !listed[fi.RelPath] {
+			report.Extra = append(report.Extra, fi.RelPath)
+		}
+	}
+
+	_, errs := hashFiles(toHash, readOptions.HashWorkers, func(fi *FileInfo) (string, error) {
+		return fi.Hash(manifest.Algorithm)
+	})
+	for i, fi := range toHash {
+		if errs[i] != nil {
+			if handler.handle(fi.Path, errs[i]) {
+				break
+			}
+			continue
+		}
+
+		if strings.EqualFold(fi.Hashes[manifest.Algorithm], toHashEntries[i].Digest) {
+			report.Verified = append(report.Verified, toHashEntries[i].RelPath)
+		} else {
+			report.Corrupted = append(report.Corrupted, toHashEntries[i].RelPath)
+		}
+	}
+
+	return report, handler.err()
+}
@@ -0,0 +1,266 @@
+package fs
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	helloSHA256 = "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"
+	worldSHA256 = "486ea46224d1bb4fb680f34f7c9ad96a8f24ec88be73ea8e5a6c65260e9cb8a7"
+	helloMD5    = "5d41402abc4b2a76b9719d911017c592"
+)
+
+func writeManifestTestFiles(dir string) error {
+	files := map[string]string{
+		"hello.txt":     "hello",
+		"sub/world.txt": "world",
+		"sub/a.txt":     "hello",
+		"sub/b.txt":     "world",
+	}
+	for name, contents := range files {
+		filename := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(filename, []byte(contents), defaultFilePermissions); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestCreateManifest(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "dir")
+	assert.Nil(err)
+	defer os.RemoveAll(dir)
+	assert.Nil(writeManifestTestFiles(dir))
+
+	manifest, err := CreateManifest(dir, SHA256, &ReadDirOptions{IncludeSubdirs: true, Sort: SortBySize})
+	assert.Nil(err)
+	for _, entry := range manifest.Entries {
+		info, err := os.Stat(filepath.Join(dir, filepath.FromSlash(entry.RelPath)))
+		assert.Nil(err)
+		assert.Equal(info.ModTime(), entry.ModTime)
+		entry.ModTime = time.Time{}
+	}
+	assert.Equal(&Manifest{
+		Algorithm: SHA256,
+		Entries: []*ManifestEntry{
+			{"hello.txt", 5, time.Time{}, helloSHA256},
+			{"sub/a.txt", 5, time.Time{}, helloSHA256},
+			{"sub/b.txt", 5, time.Time{}, worldSHA256},
+			{"sub/world.txt", 5, time.Time{}, worldSHA256},
+		},
+	}, manifest)
+
+	var gnu bytes.Buffer
+	assert.Nil(manifest.Write(&gnu, GNUManifest))
+	assert.Equal(helloSHA256+"  hello.txt\n"+
+		helloSHA256+"  sub/a.txt\n"+
+		worldSHA256+"  sub/b.txt\n"+
+		worldSHA256+"  sub/world.txt\n", gnu.String())
+
+	var bsd bytes.Buffer
+	assert.Nil(manifest.Write(&bsd, BSDManifest))
+	assert.Equal("SHA256 (hello.txt) = "+helloSHA256+"\n"+
+		"SHA256 (sub/a.txt) = "+helloSHA256+"\n"+
+		"SHA256 (sub/b.txt) = "+worldSHA256+"\n"+
+		"SHA256 (sub/world.txt) = "+worldSHA256+"\n", bsd.String())
+
+	for _, format := range []ManifestFormat{GNUManifest, BSDManifest} {
+		var buf bytes.Buffer
+		assert.Nil(manifest.Write(&buf, format))
+		read, err := ReadManifest(&buf)
+		assert.Nil(err)
+		assert.Equal(SHA256, read.Algorithm)
+		for i, entry := range read.Entries {
+			assert.Equal(manifest.Entries[i].RelPath, entry.RelPath)
+			assert.Equal(manifest.Entries[i].Digest, entry.Digest)
+			assert.Equal(int64(-1), entry.Size)
+		}
+	}
+
+	_, err = CreateManifest(dir, NoHash, &ReadDirOptions{})
+	assert.Equal(UnknownHashErr, err)
+
+	_, err = CreateManifest(dir, SHA256, nil)
+	assert.Equal(NoReadDirOptionsErr, err)
+}
+
+func TestReadManifest(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    *Manifest
+		wantErr bool
+	}{
+		{
+			"gnu",
+			helloSHA256 + "  hello.txt\n" + worldSHA256 + " *./world.txt\n",
+			&Manifest{SHA256, []*ManifestEntry{
+				{"hello.txt", -1, time.Time{}, helloSHA256},
+				{"world.txt", -1, time.Time{}, worldSHA256},
+			}},
+			false,
+		},
+		{
+			"bsd",
+			"MD5 (hello.txt) = " + helloMD5 + "\r\nMD5 (a) = b.txt) = " + helloMD5 + "\r\n",
+			&Manifest{MD5, []*ManifestEntry{
+				{"hello.txt", -1, time.Time{}, helloMD5},
+				{"a) = b.txt", -1, time.Time{}, helloMD5},
+			}},
+			false,
+		},
+		{
+			"escaped",
+			"\\" + helloMD5 + "  a\\\\b\\nc\n",
+			&Manifest{MD5, []*ManifestEntry{
+				{"a\\b\nc", -1, time.Time{}, helloMD5},
+			}},
+			false,
+		},
+		{
+			"uppercase digest",
+			strings.ToUpper(helloMD5) + "  hello.txt\n",
+			&Manifest{MD5, []*ManifestEntry{
+				{"hello.txt", -1, time.Time{}, helloMD5},
+			}},
+			false,
+		},
+		{
+			"comments",
+			"# manifest\n\n" + helloMD5 + "  hello.txt\n",
+			&Manifest{MD5, []*ManifestEntry{
+				{"hello.txt", -1, time.Time{}, helloMD5},
+			}},
+			false,
+		},
+		{
+			"empty",
+			"",
+			&Manifest{},
+			false,
+		},
+		{
+			"mixed algorithms",
+			helloMD5 + "  hello.txt\n" + helloSHA256 + "  hello.txt\n",
+			nil,
+			true,
+		},
+		{
+			"unknown digest length",
+			"abcd  hello.txt\n",
+			nil,
+			true,
+		},
+		{
+			"invalid digest",
+			strings.Repeat("x", 32) + "  hello.txt\n",
+			nil,
+			true,
+		},
+		{
+			"wrong digest length for tag",
+			"SHA1 (hello.txt) = " + helloMD5 + "\n",
+			nil,
+			true,
+		},
+		{
+			"invalid escape",
+			"\\" + helloMD5 + "  a\\tb\n",
+			nil,
+			true,
+		},
+		{
+			"duplicate path",
+			helloMD5 + "  hello.txt\n" + helloMD5 + "  ./hello.txt\n",
+			nil,
+			true,
+		},
+		{
+			"no path",
+			helloMD5 + "  \n",
+			nil,
+			true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			got, err := ReadManifest(strings.NewReader(tt.input))
+			assert.Equal(tt.want, got)
+			assert.Equal(tt.wantErr, err != nil)
+		})
+	}
+}
+
+func TestVerifyManifest(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "dir")
+	assert.Nil(err)
+	defer os.RemoveAll(dir)
+	assert.Nil(writeManifestTestFiles(dir))
+
+	options := &ReadDirOptions{IncludeSubdirs: true}
+	manifest, err := CreateManifest(dir, SHA256, options)
+	assert.Nil(err)
+
+	report, err := VerifyManifest(dir, manifest, options)
+	assert.Nil(err)
+	assert.True(report.OK())
+	assert.Len(report.Verified, 4)
+
+	assert.Nil(os.Remove(filepath.Join(dir, "hello.txt")))
+	assert.Nil(ioutil.WriteFile(filepath.Join(dir, "extra.txt"), []byte("extra"), defaultFilePermissions))
+	assert.Nil(ioutil.WriteFile(filepath.Join(dir, "sub", "world.txt"), []byte("World"), defaultFilePermissions))
+	assert.Nil(ioutil.WriteFile(filepath.Join(dir, "sub", "a.txt"), []byte("hello!"), defaultFilePermissions))
+
+	report, err = VerifyManifest(dir, manifest, options)
+	assert.Nil(err)
+	assert.False(report.OK())
+	assert.Equal(&ManifestReport{
+		Verified:  []string{"sub/b.txt"},
+		Missing:   []string{"hello.txt"},
+		Extra:     []string{"extra.txt"},
+		Corrupted: []string{"sub/a.txt", "sub/world.txt"},
+	}, report)
+
+	// Without sizes, files are only compared by digest.
+	var buf bytes.Buffer
+	assert.Nil(manifest.Write(&buf, GNUManifest))
+	read, err := ReadManifest(&buf)
+	assert.Nil(err)
+	report, err = VerifyManifest(dir, read, options)
+	assert.Nil(err)
+	assert.Equal([]string{"sub/a.txt", "sub/world.txt"}, report.Corrupted)
+
+	// Duplicate paths are verified once.
+	duplicated := &Manifest{Algorithm: SHA256}
+	for i := 0; i < 2; i++ {
+		duplicated.Entries = append(duplicated.Entries, &ManifestEntry{
+			RelPath: "sub/world.txt",
+			Size:    -1,
+			Digest:  manifest.Entries[3].Digest,
+		})
+	}
+	report, err = VerifyManifest(dir, duplicated, &ReadDirOptions{IncludeSubdirs: true, HashWorkers: 2})
+	assert.Nil(err)
+	assert.Equal([]string{"sub/world.txt"}, report.Corrupted)
+	assert.Empty(report.Verified)
+
+	_, err = VerifyManifest(dir, &Manifest{}, options)
+	assert.Equal(UnknownHashErr, err)
+}
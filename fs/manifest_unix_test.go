@@ -0,0 +1,51 @@
+//go:build !windows
+// +build !windows
+
+package fs
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestManifest_escapedPaths(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "dir")
+	assert.Nil(err)
+	defer os.RemoveAll(dir)
+	assert.Nil(ioutil.WriteFile(filepath.Join(dir, "back\\sl.txt"), []byte("hello"), defaultFilePermissions))
+	assert.Nil(ioutil.WriteFile(filepath.Join(dir, "new\nline.txt"), []byte("world"), defaultFilePermissions))
+
+	options := &ReadDirOptions{}
+	manifest, err := CreateManifest(dir, SHA256, options)
+	assert.Nil(err)
+
+	var gnu bytes.Buffer
+	assert.Nil(manifest.Write(&gnu, GNUManifest))
+	assert.Equal("\\"+helloSHA256+"  back\\\\sl.txt\n"+
+		"\\"+worldSHA256+"  new\\nline.txt\n", gnu.String())
+
+	var bsd bytes.Buffer
+	assert.Nil(manifest.Write(&bsd, BSDManifest))
+	assert.Equal("\\SHA256 (back\\\\sl.txt) = "+helloSHA256+"\n"+
+		"\\SHA256 (new\\nline.txt) = "+worldSHA256+"\n", bsd.String())
+
+	for _, buf := range []*bytes.Buffer{&gnu, &bsd} {
+		read, err := ReadManifest(buf)
+		assert.Nil(err)
+		assert.Len(read.Entries, 2)
+		assert.Equal("back\\sl.txt", read.Entries[0].RelPath)
+		assert.Equal("new\nline.txt", read.Entries[1].RelPath)
+
+		report, err := VerifyManifest(dir, read, options)
+		assert.Nil(err)
+		assert.True(report.OK())
+		assert.Equal([]string{"back\\sl.txt", "new\nline.txt"}, report.Verified)
+	}
+}
@@ -0,0 +1,122 @@
+package fs
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ReadDirPage reads a page of the directory named by the given dirname
+// following the given options, like ReadDir, and returns the files found
+// after the given cursor together with the cursor of the next page.
+// The page size is given by MaxFiles; an empty cursor requests the first page
+// and an empty next cursor is returned after the last page.
+//
+// Cursors are opaque strings that record the position of the last file
+// returned in the sort order and can only be used with the same sort options.
+// If Sort is NoSort, files are sorted by path, which allows resuming reads.
+// Pages sorted by path are read by skipping directories already returned,
+// while other orders require reading all files for each page.
+func ReadDirPage(dirname string, options *ReadDirOptions, cursor string) ([]*FileInfo, string, error) {
+	if options == nil {
+		return nil, "", NoReadDirOptionsErr
+	}
+
+	if err := AssertDir(dirname); err != nil {
+		return nil, "", err
+	}
+
+	pageOptions := *options
+	if pageOptions.Sort == NoSort {
+		pageOptions.Sort = SortByPath
+	}
+
+	var after *FileInfo
+	if cursor != "" {
+		var err error
+		if after, err = decodeCursor(cursor, dirname, &pageOptions); err != nil {
+			return nil, "", err
+		}
+	}
+
+	// Read one more file to know whether there is a next page.
+	pageSize := options.MaxFiles
+	if pageSize > 0 {
+		pageOptions.MaxFiles = pageSize + 1
+	}
+
+	r := newDirReader(dirname, &pageOptions, after)
+	fileInfos, err := r.read()
+
+	nextCursor := ""
+	if pageSize > 0 && len(fileInfos) > pageSize {
+		fileInfos = fileInfos[:pageSize]
+		nextCursor = encodeCursor(fileInfos[pageSize-1], &pageOptions)
+	}
+
+	return fileInfos, nextCursor, err
+}
+
+// pageCursor represents the position of a file in a sorted directory listing.
+type pageCursor struct {
+	Sort       SortOrder `json:"sort"`
+	Descending bool      `json:"desc,omitempty"`
+	DirsFirst  bool      `json:"dirs_first,omitempty"`
+
+	// RelPath is stored as bytes so that paths
+	// that are not valid UTF-8 are preserved.
+	RelPath []byte `json:"rel_path"`
+	IsDir   bool   `json:"is_dir,omitempty"`
+	Size    int64  `json:"size,omitempty"`
+	ModTime int64  `json:"mod_time,omitempty"`
+}
+
+func encodeCursor(fi *FileInfo, options *ReadDirOptions) string {
+	c := pageCursor{
+		Sort:       options.Sort,
+		Descending: options.SortDescending,
+		DirsFirst:  options.DirsFirst,
+		RelPath:    []byte(fi.RelPath),
+		IsDir:      fi.IsDir(),
+		Size:       fi.Size,
+		ModTime:    fi.ModTime.UnixNano(),
+	}
+
+	// Marshaling a struct of basic types cannot fail.
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor returns the file whose position is recorded
+// by the given cursor for the directory with the given dirname.
+func decodeCursor(cursor, dirname string, options *ReadDirOptions) (*FileInfo, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, InvalidCursorErr
+	}
+
+	var c pageCursor
+	if err := json.Unmarshal(data, &c); err != nil || len(c.RelPath) == 0 {
+		return nil, InvalidCursorErr
+	}
+
+	sameOrder := c.Sort == options.Sort &&
+		c.Descending == options.SortDescending &&
+		c.DirsFirst == options.DirsFirst
+	if !sameOrder {
+		return nil, CursorOrderErr
+	}
+
+	var typ os.FileMode
+	if c.IsDir {
+		typ = os.ModeDir
+	}
+
+	path := filepath.Join(dirname, filepath.FromSlash(string(c.RelPath)))
+	fi := newPathInfo(path, typ)
+	fi.Size = c.Size
+	fi.ModTime = time.Unix(0, c.ModTime)
+	return fi, nil
+}
@@ -0,0 +1,174 @@
+package fs
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadDirPage(t *testing.T) {
+	assert := assert.New(t)
+
+	wd, err := os.Getwd()
+	assert.Nil(err)
+	testdir1 := filepath.Join(filepath.Dir(wd), "testdata", "read_dir_test")
+
+	tests := []struct {
+		name    string
+		options ReadDirOptions
+	}{
+		{
+			"sort by path",
+			ReadDirOptions{
+				IncludeSubdirs: true,
+			},
+		},
+		{
+			"sort by path, include dirs",
+			ReadDirOptions{
+				IncludeSubdirs: true,
+				IncludeDirs:    true,
+			},
+		},
+		{
+			"sort by path, include dirs, dirs first",
+			ReadDirOptions{
+				IncludeSubdirs: true,
+				IncludeDirs:    true,
+				DirsFirst:      true,
+			},
+		},
+		{
+			"sort by path, descending",
+			ReadDirOptions{
+				IncludeSubdirs: true,
+				SortDescending: true,
+			},
+		},
+		{
+			"sort by natural path, 4 workers",
+			ReadDirOptions{
+				IncludeSubdirs: true,
+				Sort:           SortByNaturalPath,
+				Workers:        4,
+			},
+		},
+		{
+			"sort by size, descending, include dirs",
+			ReadDirOptions{
+				IncludeSubdirs: true,
+				IncludeDirs:    true,
+				Sort:           SortBySize,
+				SortDescending: true,
+			},
+		},
+		{
+			"sort by modification time, follow symlinks",
+			ReadDirOptions{
+				IncludeSubdirs: true,
+				Sort:           SortByModTime,
+				FollowSymlinks: true,
+			},
+		},
+	}
+	for _, tt := range tests {
+		allOptions := tt.options
+		want, wantErr := ReadDir(testdir1, &allOptions)
+		assert.Nil(wantErr, tt.name)
+
+		for _, pageSize := range []int{1, 3, len(want), 100} {
+			pageOptions := tt.options
+			pageOptions.MaxFiles = pageSize
+
+			var got []*FileInfo
+			cursor := ""
+			for pages := 0; pages <= len(want); pages++ {
+				page, nextCursor, gotErr := ReadDirPage(testdir1, &pageOptions, cursor)
+				assert.Nil(gotErr, tt.name)
+				assert.True(len(page) <= pageSize, tt.name)
+				got = append(got, page...)
+
+				cursor = nextCursor
+				if cursor == "" {
+					break
+				}
+			}
+			assert.Equal(want, got, tt.name)
+		}
+	}
+}
+
+func TestReadDirPage_errors(t *testing.T) {
+	assert := assert.New(t)
+
+	wd, err := os.Getwd()
+	assert.Nil(err)
+	testdir1 := filepath.Join(filepath.Dir(wd), "testdata", "read_dir_test")
+
+	_, cursor, err := ReadDirPage(testdir1, &ReadDirOptions{MaxFiles: 1}, "")
+	assert.Nil(err)
+	assert.NotEmpty(cursor)
+
+	type args struct {
+		dirname string
+		options *ReadDirOptions
+		cursor  string
+	}
+	tests := []struct {
+		name    string
+		args    args
+		wantErr error
+	}{
+		{
+			"invalid options",
+			args{
+				testdir1,
+				nil,
+				"",
+			},
+			NoReadDirOptionsErr,
+		},
+		{
+			"malformed cursor",
+			args{
+				testdir1,
+				&ReadDirOptions{MaxFiles: 1},
+				"not a cursor",
+			},
+			InvalidCursorErr,
+		},
+		{
+			"different sort order",
+			args{
+				testdir1,
+				&ReadDirOptions{MaxFiles: 1, Sort: SortBySize},
+				cursor,
+			},
+			CursorOrderErr,
+		},
+		{
+			"different sort direction",
+			args{
+				testdir1,
+				&ReadDirOptions{MaxFiles: 1, SortDescending: true},
+				cursor,
+			},
+			CursorOrderErr,
+		},
+		{
+			"valid cursor",
+			args{
+				testdir1,
+				&ReadDirOptions{MaxFiles: 1},
+				cursor,
+			},
+			nil,
+		},
+	}
+	for _, tt := range tests {
+		_, _, gotErr := ReadDirPage(tt.args.dirname, tt.args.options, tt.args.cursor)
+		assert.Equal(tt.wantErr, gotErr, tt.name)
+	}
+}
@@ -0,0 +1,231 @@
+package fs
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ScrubOptions represents the options available for scrubbing files.
+type ScrubOptions struct {
+	// Checkpoint, if not nil, specifies that the scrub should resume
+	// after the last file scrubbed by a previous run.
+	Checkpoint *ScrubCheckpoint
+
+	// MaxBytes, if greater than 0, specifies the number of bytes hashed
+	// after which the scrub stops and returns a checkpoint.
+	// The limit can be exceeded by the files being hashed when it is reached.
+	MaxBytes int64
+
+	// MaxDuration, if greater than 0, specifies the duration
+	// after which the scrub stops and returns a checkpoint.
+	// The limit can be exceeded by the files being hashed when it is reached.
+	MaxDuration time.Duration
+
+	// Workers is the number of files hashed concurrently.
+	// If Workers is not positive, the number of CPUs is used.
+	Workers int
+
+	// ErrorPolicy and ErrorCallback specify how errors are handled,
+	// as for ReadDir.
+	ErrorPolicy   ErrorPolicy
+	ErrorCallback func(pathname string, err error) ErrorPolicy
+}
+
+// ScrubCheckpoint represents the position reached by a scrub.
+type ScrubCheckpoint struct {
+	LastPath string // slash-separated path of the last file scrubbed
+}
+
+// ScrubReport represents the result of a scrub.
+type ScrubReport struct {
+	OK        []string // files with the stored contents
+	Modified  []string // files modified since their digest was stored
+	Corrupted []string // files with different contents but the same modification time
+	Missing   []string // files not found
+
+	BytesHashed int64 // number of bytes hashed
+
+	// Checkpoint is the checkpoint from which a later run should resume,
+	// or nil if all files were scrubbed. A scrub stopped by a limit or
+	// by an error returns the checkpoint of the last batch of files
+	// fully scrubbed; files of a batch being scrubbed when an error
+	// stops the scrub are scrubbed again when resuming.
+	Checkpoint *ScrubCheckpoint
+}
+
+// Scrub re-hashes the regular files of the given manifest, found in the
+// directory named by the given dirname, and compares them with the stored
+// digests, in path order.
+// Files whose modification time differs from the one in the manifest
+// are reported as modified without being hashed; files with the same
+// modification time, or without a stored one, whose size or contents differ
+// are reported as corrupted.
+// Manifests read with ReadManifest hold no modification times, so all their
+// changed files are reported as corrupted; to tell modified files apart,
+// scrub the manifest of a snapshot taken with hashes, saved with
+// Snapshot.Save and loaded with LoadSnapshot.
+// Errors are handled as specified by the options and are returned
+// together with the report of the files scrubbed.
+func Scrub(dirname string, manifest *Manifest, options *ScrubOptions) (*ScrubReport, error) {
+	if options == nil {
+		options = &ScrubOptions{}
+	}
+	if _, err := newHash(manifest.Algorithm); err != nil {
+		return nil, err
+	}
+
+	entries := make([]*ManifestEntry, len(manifest.Entries))
+	copy(entries, manifest.Entries)
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].RelPath < entries[j].RelPath
+	})
+
+	start := 0
+	var checkpoint ScrubCheckpoint
+	if options.Checkpoint != nil {
+		checkpoint = *options.Checkpoint
+		start = sort.Search(len(entries), func(i int) bool {
+			return entries[i].RelPath > options.Checkpoint.LastPath
+		})
+	}
+
+	s := &scrubber{
+		dirname:   dirname,
+		algorithm: manifest.Algorithm,
+		options:   options,
+		handler: errorHandler{options: &ReadDirOptions{
+			ErrorPolicy:   options.ErrorPolicy,
+			ErrorCallback: options.ErrorCallback,
+		}},
+		report: &ScrubReport{},
+	}
+	s.scrub(entries[start:], checkpoint)
+
+	report := s.report
+	sort.Strings(report.OK)
+	sort.Strings(report.Modified)
+	sort.Strings(report.Corrupted)
+	sort.Strings(report.Missing)
+	return report, s.handler.err()
+}
+
+// scrubber scrubs the files of a manifest.
+type scrubber struct {
+	dirname   string
+	algorithm HashAlgorithm
+	options   *ScrubOptions
+	handler   errorHandler
+	report    *ScrubReport
+}
+
+// scrub scrubs the given entries, sorted by path, resuming from the given
+// checkpoint. Entries are hashed in batches and the scrub stops after
+// a batch if a limit is reached.
+func (s *scrubber) scrub(entries []*ManifestEntry, checkpoint ScrubCheckpoint) {
+	workers := s.options.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	startTime := time.Now()
+	for i := 0; i < len(entries); {
+		var batch []*FileInfo
+		var batchEntries []*ManifestEntry
+		for ; i < len(entries) && len(batch) < workers; i++ {
+			fi, ok := s.check(entries[i])
+			if s.handler.aborted() {
+				s.report.Checkpoint = &checkpoint
+				return
+			}
+			if ok {
+				batch = append(batch, fi)
+				batchEntries = append(batchEntries, entries[i])
+			}
+		}
+
+		s.hash(batch, batchEntries)
+		if s.handler.aborted() {
+			s.report.Checkpoint = &checkpoint
+			return
+		}
+
+		checkpoint.LastPath = entries[i-1].RelPath
+		if i < len(entries) && s.limitReached(startTime) {
+			s.report.Checkpoint = &checkpoint
+			return
+		}
+	}
+}
+
+// limitReached returns true if a limit of the scrub is reached.
+func (s *scrubber) limitReached(startTime time.Time) bool {
+	return (s.options.MaxBytes > 0 && s.report.BytesHashed >= s.options.MaxBytes) ||
+		(s.options.MaxDuration > 0 && time.Since(startTime) >= s.options.MaxDuration)
+}
+
+// check compares the metadata of the file of the given entry with
+// the stored one and returns the file and true if it should be hashed.
+func (s *scrubber) check(entry *ManifestEntry) (*FileInfo, bool) {
+	filename := filepath.Join(s.dirname, filepath.FromSlash(entry.RelPath))
+	info, err := os.Stat(filename)
+	switch {
+	case os.IsNotExist(err):
+		s.report.Missing = append(s.report.Missing, entry.RelPath)
+		return nil, false
+	case err != nil:
+		s.handler.handle(filename, err)
+		return nil, false
+	case !info.Mode().IsRegular() || s.modified(entry, info):
+		s.report.Modified = append(s.report.Modified, entry.RelPath)
+		return nil, false
+	case entry.Size >= 0 && entry.Size != info.Size():
+		s.report.Corrupted = append(s.report.Corrupted, entry.RelPath)
+		return nil, false
+	}
+
+	return newFileInfo(filename, info), true
+}
+
+// modified returns true if the modification time of the given file
+// differs from the one of the given entry.
+func (s *scrubber) modified(entry *ManifestEntry, info os.FileInfo) bool {
+	return !entry.ModTime.IsZero() && !entry.ModTime.Equal(info.ModTime())
+}
+
+// hash hashes the given files and compares them with the given entries.
+// Files modified while being hashed are reported as modified.
+func (s *scrubber) hash(fileInfos []*FileInfo, entries []*ManifestEntry) {
+	digests, errs := hashFiles(fileInfos, s.options.Workers, func(fi *FileInfo) (string, error) {
+		return HashFile(fi.Path, s.algorithm)
+	})
+
+	for i, fi := range fileInfos {
+		if errs[i] != nil {
+			if s.handler.handle(fi.Path, errs[i]) {
+				return
+			}
+			continue
+		}
+		s.report.BytesHashed += fi.Size
+
+		entry := entries[i]
+		switch {
+		case strings.EqualFold(digests[i], entry.Digest):
+			s.report.OK = append(s.report.OK, entry.RelPath)
+		case s.changed(fi):
+			s.report.Modified = append(s.report.Modified, entry.RelPath)
+		default:
+			s.report.Corrupted = append(s.report.Corrupted, entry.RelPath)
+		}
+	}
+}
+
+// changed returns true if the given file changed since it was read.
+func (s *scrubber) changed(fi *FileInfo) bool {
+	info, err := os.Stat(fi.Path)
+	return err != nil || !info.ModTime().Equal(fi.ModTime) || info.Size() != fi.Size
+}
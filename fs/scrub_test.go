@@ -0,0 +1,172 @@
+package fs
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScrub(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "dir")
+	assert.Nil(err)
+	defer os.RemoveAll(dir)
+	assert.Nil(writeManifestTestFiles(dir))
+	readOptions := &ReadDirOptions{IncludeSubdirs: true}
+	manifest, err := CreateManifest(dir, SHA256, readOptions)
+	assert.Nil(err)
+
+	report, err := Scrub(dir, manifest, nil)
+	assert.Nil(err)
+	assert.Equal(&ScrubReport{
+		OK:          []string{"hello.txt", "sub/a.txt", "sub/b.txt", "sub/world.txt"},
+		BytesHashed: 20,
+	}, report)
+
+	// Corrupt contents while keeping the modification time.
+	corrupted := filepath.Join(dir, "sub", "world.txt")
+	info, err := os.Stat(corrupted)
+	assert.Nil(err)
+	assert.Nil(ioutil.WriteFile(corrupted, []byte("wOrld"), defaultFilePermissions))
+	assert.Nil(os.Chtimes(corrupted, info.ModTime(), info.ModTime()))
+
+	modified := filepath.Join(dir, "hello.txt")
+	assert.Nil(ioutil.WriteFile(modified, []byte("hello, world"), defaultFilePermissions))
+	modTime := info.ModTime().Add(time.Hour)
+	assert.Nil(os.Chtimes(modified, modTime, modTime))
+
+	assert.Nil(os.Remove(filepath.Join(dir, "sub", "b.txt")))
+
+	report, err = Scrub(dir, manifest, &ScrubOptions{Workers: 2})
+	assert.Nil(err)
+	assert.Equal(&ScrubReport{
+		OK:          []string{"sub/a.txt"},
+		Modified:    []string{"hello.txt"},
+		Corrupted:   []string{"sub/world.txt"},
+		Missing:     []string{"sub/b.txt"},
+		BytesHashed: 10,
+	}, report)
+
+	// Without modification times, changed files are reported as corrupted.
+	for _, entry := range manifest.Entries {
+		entry.Size = -1
+		entry.ModTime = time.Time{}
+	}
+	report, err = Scrub(dir, manifest, nil)
+	assert.Nil(err)
+	assert.Equal([]string{"hello.txt", "sub/world.txt"}, report.Corrupted)
+
+	_, err = Scrub(dir, &Manifest{}, nil)
+	assert.Equal(UnknownHashErr, err)
+}
+
+func TestScrub_savedSnapshot(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "dir")
+	assert.Nil(err)
+	defer os.RemoveAll(dir)
+	assert.Nil(writeManifestTestFiles(dir))
+	snapshot, err := TakeSnapshot(dir, &SnapshotOptions{
+		ReadDirOptions: &ReadDirOptions{IncludeSubdirs: true},
+		Hash:           true,
+	})
+	assert.Nil(err)
+
+	var buf bytes.Buffer
+	assert.Nil(snapshot.Save(&buf))
+	loaded, err := LoadSnapshot(&buf)
+	assert.Nil(err)
+
+	corrupted := filepath.Join(dir, "sub", "world.txt")
+	info, err := os.Stat(corrupted)
+	assert.Nil(err)
+	assert.Nil(ioutil.WriteFile(corrupted, []byte("wOrld"), defaultFilePermissions))
+	assert.Nil(os.Chtimes(corrupted, info.ModTime(), info.ModTime()))
+
+	modified := filepath.Join(dir, "hello.txt")
+	assert.Nil(ioutil.WriteFile(modified, []byte("hello!"), defaultFilePermissions))
+	modTime := info.ModTime().Add(time.Hour)
+	assert.Nil(os.Chtimes(modified, modTime, modTime))
+
+	report, err := Scrub(dir, loaded.Manifest(), nil)
+	assert.Nil(err)
+	assert.Equal(&ScrubReport{
+		OK:          []string{"sub/a.txt", "sub/b.txt"},
+		Modified:    []string{"hello.txt"},
+		Corrupted:   []string{"sub/world.txt"},
+		BytesHashed: 15,
+	}, report)
+}
+
+func TestScrub_checkpoint(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "dir")
+	assert.Nil(err)
+	defer os.RemoveAll(dir)
+	assert.Nil(writeManifestTestFiles(dir))
+	snapshot, err := TakeSnapshot(dir, &SnapshotOptions{
+		ReadDirOptions: &ReadDirOptions{IncludeSubdirs: true},
+		Hash:           true,
+	})
+	assert.Nil(err)
+	manifest := snapshot.Manifest()
+	assert.Len(manifest.Entries, 4)
+
+	var scrubbed []string
+	options := &ScrubOptions{MaxBytes: 6, Workers: 1}
+	runs := 0
+	for {
+		report, err := Scrub(dir, manifest, options)
+		assert.Nil(err)
+		assert.Empty(report.Corrupted)
+		scrubbed = append(scrubbed, report.OK...)
+		runs++
+		if report.Checkpoint == nil {
+			break
+		}
+		options.Checkpoint = report.Checkpoint
+	}
+
+	assert.Equal(2, runs)
+	assert.Equal([]string{"hello.txt", "sub/a.txt", "sub/b.txt", "sub/world.txt"}, scrubbed)
+}
+
+func TestScrub_abort(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "dir")
+	assert.Nil(err)
+	defer os.RemoveAll(dir)
+	assert.Nil(writeManifestTestFiles(dir))
+	manifest, err := CreateManifest(dir, SHA256, &ReadDirOptions{IncludeSubdirs: true})
+	assert.Nil(err)
+
+	// A path under a regular file cannot be stat'ed.
+	manifest.Entries = append(manifest.Entries, &ManifestEntry{
+		RelPath: "hello.txt/x",
+		Size:    -1,
+		Digest:  helloSHA256,
+	})
+
+	report, err := Scrub(dir, manifest, &ScrubOptions{Workers: 1, ErrorPolicy: AbortOnError})
+	assert.NotNil(err)
+	assert.Equal([]string{"hello.txt"}, report.OK)
+	assert.Equal(&ScrubCheckpoint{LastPath: "hello.txt"}, report.Checkpoint)
+
+	report, err = Scrub(dir, manifest, &ScrubOptions{Workers: 4, ErrorPolicy: AbortOnError})
+	assert.NotNil(err)
+	assert.Equal(&ScrubCheckpoint{}, report.Checkpoint)
+
+	report, err = Scrub(dir, manifest, &ScrubOptions{Checkpoint: report.Checkpoint, Workers: 1})
+	assert.Nil(err)
+	assert.Nil(report.Checkpoint)
+	assert.Equal([]string{"hello.txt", "sub/a.txt", "sub/b.txt", "sub/world.txt"}, report.OK)
+}
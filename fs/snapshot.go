@@ -0,0 +1,253 @@
+package fs
+
+import (
+	"encoding/gob"
+	"io"
+	"os"
+	"time"
+)
+
+// Snapshot represents the state of a directory tree at a given time.
+type Snapshot struct {
+	Root    string           // directory from which the snapshot was taken
+	Time    time.Time        // time at which the snapshot was taken
+	Entries []*SnapshotEntry // files in the snapshot, sorted by path
+}
+
+// SnapshotEntry represents the state of a file in a snapshot.
+type SnapshotEntry struct {
+	RelPath string      // slash-separated path relative to the snapshot root
+	Size    int64       // file size in bytes
+	Mode    os.FileMode // file mode bits
+	ModTime time.Time   // modification time
+	Dev     uint64      // device containing the file
+	Inode   uint64      // file serial number within the device
+	Hash    string      // hex SHA-256 digest of a regular file's contents, if computed
+}
+
+// SnapshotOptions represents the options available for taking snapshots.
+type SnapshotOptions struct {
+	// ReadDirOptions specifies the files included in the snapshot.
+	// Sort, MaxFiles and Hash options are ignored.
+	ReadDirOptions *ReadDirOptions
+
+	// Hash, if true, specifies that the contents of regular files
+	// should be hashed, which allows detecting files moved across devices
+	// or copied and removed.
+	Hash bool
+}
+
+// SnapshotDiff represents the differences between two snapshots.
+type SnapshotDiff struct {
+	Added    []*SnapshotEntry  // files only in the new snapshot
+	Removed  []*SnapshotEntry  // files only in the old snapshot
+	Modified []*SnapshotChange // files at the same path with different states
+	Moved    []*SnapshotChange // files at different paths with the same identity
+}
+
+// SnapshotChange represents a file changed between two snapshots.
+type SnapshotChange struct {
+	Old *SnapshotEntry // state in the old snapshot
+	New *SnapshotEntry // state in the new snapshot
+}
+
+// TakeSnapshot reads the directory named by the given dirname
+// following the given options and returns a snapshot of the files found.
+// Errors are handled as specified by the ReadDirOptions and are returned
+// together with the snapshot of the files read.
+func TakeSnapshot(dirname string, options *SnapshotOptions) (*Snapshot, error) {
+	if options == nil || options.ReadDirOptions == nil {
+		return nil, NoReadDirOptionsErr
+	}
+
+	readOptions := pathOrderOptions(options.ReadDirOptions)
+	readOptions.SkipStat = true
+
+	snapshotTime := time.Now()
+	fileInfos, err := ReadDir(dirname, readOptions)
+	if fileInfos == nil && err != nil {
+		return nil, err
+	}
+
+	handler := errorHandler{options: readOptions}
+	handler.add(err)
+
+	entries := make([]*SnapshotEntry, 0, len(fileInfos))
+	for _, fi := range fileInfos {
+		entry, err := newSnapshotEntry(fi, options.Hash)
+		if err != nil {
+			if handler.handle(fi.Path, err) {
+				break
+			}
+			continue
+		}
+		entries = append(entries, entry)
+	}
+
+	snapshot := &Snapshot{
+		Root:    dirname,
+		Time:    snapshotTime,
+		Entries: entries,
+	}
+	return snapshot, handler.err()
+}
+
+func newSnapshotEntry(fi *FileInfo, hash bool) (*SnapshotEntry, error) {
+	info, err := fi.stat()
+	if err != nil {
+		return nil, err
+	}
+	id, err := getFileID(fi.Path, info)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := &SnapshotEntry{
+		RelPath: fi.RelPath,
+		Size:    info.Size(),
+		Mode:    info.Mode(),
+		ModTime: info.ModTime(),
+		Dev:     id.dev,
+		Inode:   id.ino,
+	}
+	if hash && info.Mode().IsRegular() {
+		if entry.Hash, err = fi.Hash(SHA256); err != nil {
+			return nil, err
+		}
+	}
+
+	return entry, nil
+}
+
+// Save writes the snapshot to the given writer.
+// Saved snapshots can be read with LoadSnapshot.
+func (s *Snapshot) Save(w io.Writer) error {
+	return gob.NewEncoder(w).Encode(s)
+}
+
+// LoadSnapshot reads a snapshot saved with Snapshot.Save from the given reader.
+func LoadSnapshot(r io.Reader) (*Snapshot, error) {
+	var s Snapshot
+	if err := gob.NewDecoder(r).Decode(&s); err != nil {
+		return nil, err
+	}
+
+	return &s, nil
+}
+
+// Manifest returns a manifest of the regular files of the snapshot
+// whose contents were hashed, with their sizes and modification times,
+// suitable for Scrub.
+func (s *Snapshot) Manifest() *Manifest {
+	manifest := &Manifest{Algorithm: SHA256}
+	for _, entry := range s.Entries {
+		if entry.Mode.IsRegular() && entry.Hash != "" {
+			manifest.Entries = append(manifest.Entries, &ManifestEntry{
+				RelPath: entry.RelPath,
+				Size:    entry.Size,
+				ModTime: entry.ModTime,
+				Digest:  entry.Hash,
+			})
+		}
+	}
+	return manifest
+}
+
+// DiffSnapshots returns the differences between the given snapshots.
+// Files present at different paths in the two snapshots are reported as
+// moved if they have the same type and size and either the same device
+// and inode or, failing that, the same contents hash.
+func DiffSnapshots(oldSnapshot, newSnapshot *Snapshot) *SnapshotDiff {
+	diff := &SnapshotDiff{}
+
+	oldEntries := make(map[string]*SnapshotEntry, len(oldSnapshot.Entries))
+	for _, entry := range oldSnapshot.Entries {
+		oldEntries[entry.RelPath] = entry
+	}
+	newEntries := make(map[string]*SnapshotEntry, len(newSnapshot.Entries))
+	for _, entry := range newSnapshot.Entries {
+		newEntries[entry.RelPath] = entry
+	}
+
+	// Removed files are candidates for moves, by identity or contents.
+	var removed []*SnapshotEntry
+	byID := make(map[fileID][]*SnapshotEntry)
+	byHash := make(map[string][]*SnapshotEntry)
+	for _, oldEntry := range oldSnapshot.Entries {
+		if _, ok := newEntries[oldEntry.RelPath]; ok {
+			continue
+		}
+
+		removed = append(removed, oldEntry)
+		if oldEntry.Inode != 0 {
+			id := oldEntry.fileID()
+			byID[id] = append(byID[id], oldEntry)
+		}
+		if oldEntry.Hash != "" {
+			byHash[oldEntry.Hash] = append(byHash[oldEntry.Hash], oldEntry)
+		}
+	}
+
+	moved := make(map[*SnapshotEntry]bool)
+	for _, newEntry := range newSnapshot.Entries {
+		oldEntry, ok := oldEntries[newEntry.RelPath]
+		if ok {
+			if !oldEntry.sameState(newEntry) {
+				diff.Modified = append(diff.Modified, &SnapshotChange{Old: oldEntry, New: newEntry})
+			}
+			continue
+		}
+
+		oldEntry = popMoved(byID[newEntry.fileID()], newEntry, moved)
+		if oldEntry == nil && newEntry.Hash != "" {
+			oldEntry = popMoved(byHash[newEntry.Hash], newEntry, moved)
+		}
+		if oldEntry != nil {
+			diff.Moved = append(diff.Moved, &SnapshotChange{Old: oldEntry, New: newEntry})
+			continue
+		}
+
+		diff.Added = append(diff.Added, newEntry)
+	}
+
+	for _, oldEntry := range removed {
+		if !moved[oldEntry] {
+			diff.Removed = append(diff.Removed, oldEntry)
+		}
+	}
+
+	return diff
+}
+
+// popMoved returns the first of the given candidates that is not already
+// moved and has the same type and size as the given entry, marking it as moved.
+func popMoved(candidates []*SnapshotEntry, entry *SnapshotEntry, moved map[*SnapshotEntry]bool) *SnapshotEntry {
+	for _, candidate := range candidates {
+		sameFile := candidate.Mode.IsDir() == entry.Mode.IsDir() &&
+			(candidate.Mode.IsDir() || candidate.Size == entry.Size)
+		if !moved[candidate] && sameFile {
+			moved[candidate] = true
+			return candidate
+		}
+	}
+
+	return nil
+}
+
+func (e *SnapshotEntry) fileID() fileID {
+	return fileID{dev: e.Dev, ino: e.Inode}
+}
+
+// sameState returns true if the given entry, at the same path,
+// represents the same unmodified file.
+// The size and modification time of directories are ignored.
+func (e *SnapshotEntry) sameState(other *SnapshotEntry) bool {
+	if e.Mode != other.Mode || e.fileID() != other.fileID() || e.Hash != other.Hash {
+		return false
+	}
+	if e.Mode.IsDir() {
+		return true
+	}
+
+	return e.Size == other.Size && e.ModTime.Equal(other.ModTime)
+}
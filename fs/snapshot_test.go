@@ -0,0 +1,185 @@
+package fs
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTakeSnapshot(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "dir")
+	assert.Nil(err)
+	defer os.RemoveAll(dir)
+	modTime := time.Now().Add(-time.Hour).Truncate(time.Second)
+	assert.Nil(os.Mkdir(filepath.Join(dir, "a"), 0755))
+	assert.Nil(writeTestFile(filepath.Join(dir, "1.gif"), 10, modTime))
+	assert.Nil(writeTestFile(filepath.Join(dir, "a", "2.gif"), 0, modTime))
+
+	tests := []struct {
+		name      string
+		dirname   string
+		options   *SnapshotOptions
+		wantPaths []string
+		wantHash  bool
+		wantErr   bool
+	}{
+		{
+			"nil options",
+			dir,
+			nil,
+			nil,
+			false,
+			true,
+		},
+		{
+			"nil read options",
+			dir,
+			&SnapshotOptions{},
+			nil,
+			false,
+			true,
+		},
+		{
+			"invalid dir",
+			"",
+			&SnapshotOptions{ReadDirOptions: &ReadDirOptions{}},
+			nil,
+			false,
+			true,
+		},
+		{
+			"include subdirs and dirs",
+			dir,
+			&SnapshotOptions{
+				ReadDirOptions: &ReadDirOptions{
+					IncludeSubdirs: true,
+					IncludeDirs:    true,
+					Sort:           SortBySize,
+					MaxFiles:       1,
+				},
+			},
+			[]string{"1.gif", "a", "a/2.gif"},
+			false,
+			false,
+		},
+		{
+			"hash contents",
+			dir,
+			&SnapshotOptions{
+				ReadDirOptions: &ReadDirOptions{},
+				Hash:           true,
+			},
+			[]string{"1.gif"},
+			true,
+			false,
+		},
+	}
+	for _, tt := range tests {
+		got, err := TakeSnapshot(tt.dirname, tt.options)
+		if tt.wantErr {
+			assert.NotNil(err, tt.name)
+			assert.Nil(got, tt.name)
+			continue
+		}
+		assert.Nil(err, tt.name)
+		assert.Equal(tt.dirname, got.Root, tt.name)
+
+		var gotPaths []string
+		for _, entry := range got.Entries {
+			gotPaths = append(gotPaths, entry.RelPath)
+
+			info, err := os.Lstat(filepath.Join(dir, filepath.FromSlash(entry.RelPath)))
+			assert.Nil(err, tt.name)
+			assert.Equal(info.Size(), entry.Size, tt.name)
+			assert.Equal(info.Mode(), entry.Mode, tt.name)
+			assert.True(info.ModTime().Equal(entry.ModTime), tt.name)
+			assert.NotZero(entry.Inode, tt.name)
+			assert.Equal(tt.wantHash, entry.Hash != "", tt.name)
+		}
+		assert.Equal(tt.wantPaths, gotPaths, tt.name)
+
+		var buf bytes.Buffer
+		assert.Nil(got.Save(&buf), tt.name)
+		loaded, err := LoadSnapshot(&buf)
+		assert.Nil(err, tt.name)
+		assert.Equal(got.Root, loaded.Root, tt.name)
+		assert.True(got.Time.Equal(loaded.Time), tt.name)
+		assert.Equal(len(got.Entries), len(loaded.Entries), tt.name)
+		for i, entry := range loaded.Entries {
+			assert.True(got.Entries[i].ModTime.Equal(entry.ModTime), tt.name)
+			entry.ModTime = got.Entries[i].ModTime
+			assert.Equal(got.Entries[i], entry, tt.name)
+		}
+	}
+
+	_, err = LoadSnapshot(bytes.NewReader([]byte("invalid")))
+	assert.NotNil(err)
+}
+
+func TestDiffSnapshots(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "dir")
+	assert.Nil(err)
+	defer os.RemoveAll(dir)
+	modTime := time.Now().Add(-time.Hour)
+	assert.Nil(os.Mkdir(filepath.Join(dir, "a"), 0755))
+	assert.Nil(os.Mkdir(filepath.Join(dir, "b"), 0755))
+	assert.Nil(writeTestFile(filepath.Join(dir, "a", "1.gif"), 10, modTime))
+	assert.Nil(writeTestFile(filepath.Join(dir, "a", "2.gif"), 20, modTime))
+	assert.Nil(writeTestFile(filepath.Join(dir, "3.txt"), 30, modTime))
+	assert.Nil(writeTestFile(filepath.Join(dir, "4.txt"), 40, modTime))
+
+	options := &SnapshotOptions{
+		ReadDirOptions: &ReadDirOptions{
+			IncludeSubdirs: true,
+			IncludeDirs:    true,
+		},
+		Hash: true,
+	}
+	oldSnapshot, err := TakeSnapshot(dir, options)
+	assert.Nil(err)
+
+	unchanged := DiffSnapshots(oldSnapshot, oldSnapshot)
+	assert.Equal(&SnapshotDiff{}, unchanged)
+
+	// Moved by inode.
+	assert.Nil(os.Rename(filepath.Join(dir, "a", "1.gif"), filepath.Join(dir, "b", "1.gif")))
+	// Moved by contents.
+	assert.Nil(writeTestFile(filepath.Join(dir, "c.gif"), 20, modTime))
+	assert.Nil(os.Remove(filepath.Join(dir, "a", "2.gif")))
+	// Modified, removed and added.
+	assert.Nil(writeTestFile(filepath.Join(dir, "3.txt"), 31, modTime))
+	assert.Nil(os.Remove(filepath.Join(dir, "4.txt")))
+	assert.Nil(writeTestFile(filepath.Join(dir, "5.txt"), 50, modTime))
+
+	newSnapshot, err := TakeSnapshot(dir, options)
+	assert.Nil(err)
+
+	diff := DiffSnapshots(oldSnapshot, newSnapshot)
+	assert.Equal([]string{"5.txt"}, snapshotEntriesPaths(diff.Added))
+	assert.Equal([]string{"4.txt"}, snapshotEntriesPaths(diff.Removed))
+	assert.Equal(1, len(diff.Modified))
+	assert.Equal("3.txt", diff.Modified[0].Old.RelPath)
+	assert.Equal(int64(31), diff.Modified[0].New.Size)
+	assert.Equal(2, len(diff.Moved))
+	assert.Equal("a/1.gif", diff.Moved[0].Old.RelPath)
+	assert.Equal("b/1.gif", diff.Moved[0].New.RelPath)
+	assert.Equal("a/2.gif", diff.Moved[1].Old.RelPath)
+	assert.Equal("c.gif", diff.Moved[1].New.RelPath)
+}
+
+func snapshotEntriesPaths(entries []*SnapshotEntry) []string {
+	var paths []string
+	for _, entry := range entries {
+		paths = append(paths, entry.RelPath)
+	}
+	return paths
+}
@@ -0,0 +1,192 @@
+package fs
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// SortOrder represents the order in which ReadDir sorts the files read.
+type SortOrder int
+
+const (
+	// SortByPath sorts files by lexical path order.
+	SortByPath SortOrder = iota
+
+	// SortByNaturalPath sorts files by natural path order, in which
+	// numbers are compared by their value, so that, for example,
+	// "file2.gif" comes before "file10.gif".
+	SortByNaturalPath
+
+	// SortBySize sorts files by size.
+	SortBySize
+
+	// SortByModTime sorts files by modification time.
+	SortByModTime
+
+	// SortByExt sorts files by extension.
+	SortByExt
+
+	// NoSort leaves files in the order in which they are found
+	// in the filesystem, which is faster for large directories.
+	NoSort
+)
+
+// sortFileInfos sorts the given files following the given order.
+// Files that are equal for the given order are sorted by lexical path order.
+// If dirsFirst is true, directories come before other files.
+func sortFileInfos(fileInfos []*FileInfo, order SortOrder, descending, dirsFirst bool) {
+	if order == NoSort && !dirsFirst {
+		return
+	}
+
+	sort.SliceStable(fileInfos, func(i, j int) bool {
+		return lessFileInfos(fileInfos[i], fileInfos[j], order, descending, dirsFirst)
+	})
+}
+
+// filesAfter returns the files that come after the given file
+// in the given sorted list of files.
+func filesAfter(fileInfos []*FileInfo, after *FileInfo, order SortOrder, descending, dirsFirst bool) []*FileInfo {
+	start := sort.Search(len(fileInfos), func(i int) bool {
+		return lessFileInfos(after, fileInfos[i], order, descending, dirsFirst)
+	})
+	return fileInfos[start:]
+}
+
+// lessFileInfos returns true if fi1 comes before fi2 following the given order.
+func lessFileInfos(fi1, fi2 *FileInfo, order SortOrder, descending, dirsFirst bool) bool {
+	if dirsFirst && fi1.IsDir() != fi2.IsDir() {
+		return fi1.IsDir()
+	}
+	if order == NoSort {
+		return false
+	}
+	if descending {
+		return compareFileInfos(fi2, fi1, order) < 0
+	}
+	return compareFileInfos(fi1, fi2, order) < 0
+}
+
+// compareFileInfos compares the given files following the given order
+// and returns -1, 0 or 1 if fi1 comes before, together with or after fi2.
+func compareFileInfos(fi1, fi2 *FileInfo, order SortOrder) int {
+	switch order {
+	case SortByNaturalPath:
+		if c := compareNatural(fi1.Path, fi2.Path); c != 0 {
+			return c
+		}
+	case SortBySize:
+		if fi1.Size != fi2.Size {
+			return compareBool(fi1.Size < fi2.Size)
+		}
+	case SortByModTime:
+		if !fi1.ModTime.Equal(fi2.ModTime) {
+			return compareBool(fi1.ModTime.Before(fi2.ModTime))
+		}
+	case SortByExt:
+		if c := strings.Compare(fi1.Ext, fi2.Ext); c != 0 {
+			return c
+		}
+	}
+
+	return comparePaths(fi1.Path, fi2.Path)
+}
+
+func compareBool(less bool) int {
+	if less {
+		return -1
+	}
+	return 1
+}
+
+// comparePaths compares the given paths by lexical order
+// and returns -1, 0 or 1 if path1 comes before, is equal to or comes after path2.
+// Path separators come before any other character, so that the files
+// inside a directory come right after the directory itself,
+// as when walking a directory.
+func comparePaths(path1, path2 string) int {
+	n := len(path1)
+	if len(path2) < n {
+		n = len(path2)
+	}
+
+	for i := 0; i < n; i++ {
+		c1, c2 := path1[i], path2[i]
+		if c1 == c2 {
+			continue
+		}
+		if c1 == filepath.Separator {
+			return -1
+		}
+		if c2 == filepath.Separator {
+			return 1
+		}
+		return compareBool(c1 < c2)
+	}
+
+	if len(path1) == len(path2) {
+		return 0
+	}
+	return compareBool(len(path1) < len(path2))
+}
+
+// compareNatural compares the given paths by natural order
+// and returns -1, 0 or 1 if path1 comes before, is equal to or comes after path2.
+// Sequences of digits are compared by their numeric value;
+// paths that are naturally equal, such as "file01" and "file1",
+// are compared by lexical order.
+func compareNatural(path1, path2 string) int {
+	i, j := 0, 0
+	for i < len(path1) && j < len(path2) {
+		c1, c2 := path1[i], path2[j]
+
+		if isDigit(c1) && isDigit(c2) {
+			end1, end2 := digitsEnd(path1, i), digitsEnd(path2, j)
+			num1 := strings.TrimLeft(path1[i:end1], "0")
+			num2 := strings.TrimLeft(path2[j:end2], "0")
+			if len(num1) != len(num2) {
+				return compareBool(len(num1) < len(num2))
+			}
+			if c := strings.Compare(num1, num2); c != 0 {
+				return c
+			}
+			i, j = end1, end2
+			continue
+		}
+
+		if c1 != c2 {
+			if c1 == filepath.Separator {
+				return -1
+			}
+			if c2 == filepath.Separator {
+				return 1
+			}
+			return compareBool(c1 < c2)
+		}
+		i++
+		j++
+	}
+
+	if i < len(path1) {
+		return 1
+	}
+	if j < len(path2) {
+		return -1
+	}
+	return comparePaths(path1, path2)
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+// digitsEnd returns the index following the sequence of digits
+// starting at the given index in s.
+func digitsEnd(s string, start int) int {
+	end := start
+	for end < len(s) && isDigit(s[end]) {
+		end++
+	}
+	return end
+}
@@ -0,0 +1,186 @@
+package fs
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_comparePaths(t *testing.T) {
+	assert := assert.New(t)
+
+	sep := string(filepath.Separator)
+
+	type args struct {
+		path1 string
+		path2 string
+	}
+	tests := []struct {
+		name string
+		args args
+		want int
+	}{
+		{
+			"equal paths",
+			args{
+				"a" + sep + "b",
+				"a" + sep + "b",
+			},
+			0,
+		},
+		{
+			"lexical order",
+			args{
+				"a" + sep + "b",
+				"a" + sep + "c",
+			},
+			-1,
+		},
+		{
+			"lexical order, reversed",
+			args{
+				"a" + sep + "c",
+				"a" + sep + "b",
+			},
+			1,
+		},
+		{
+			"prefix comes first",
+			args{
+				"a",
+				"a" + sep + "b",
+			},
+			-1,
+		},
+		{
+			"directory contents come before siblings",
+			args{
+				"a" + sep + "b",
+				"a.b",
+			},
+			-1,
+		},
+		{
+			"directory contents come before siblings, reversed",
+			args{
+				"a.b",
+				"a" + sep + "b",
+			},
+			1,
+		},
+		{
+			"numbers are compared lexically",
+			args{
+				"file10.gif",
+				"file2.gif",
+			},
+			-1,
+		},
+	}
+	for _, tt := range tests {
+		got := comparePaths(tt.args.path1, tt.args.path2)
+		assert.Equal(tt.want, got, tt.name)
+	}
+}
+
+func Test_compareNatural(t *testing.T) {
+	assert := assert.New(t)
+
+	sep := string(filepath.Separator)
+
+	type args struct {
+		path1 string
+		path2 string
+	}
+	tests := []struct {
+		name string
+		args args
+		want int
+	}{
+		{
+			"equal paths",
+			args{
+				"file10.gif",
+				"file10.gif",
+			},
+			0,
+		},
+		{
+			"numbers are compared by value",
+			args{
+				"file2.gif",
+				"file10.gif",
+			},
+			-1,
+		},
+		{
+			"numbers are compared by value, reversed",
+			args{
+				"file10.gif",
+				"file2.gif",
+			},
+			1,
+		},
+		{
+			"multiple numbers",
+			args{
+				"img2-10.gif",
+				"img2-9.gif",
+			},
+			1,
+		},
+		{
+			"leading zeros are ignored",
+			args{
+				"file002.gif",
+				"file10.gif",
+			},
+			-1,
+		},
+		{
+			"naturally equal paths are compared lexically",
+			args{
+				"file01.gif",
+				"file1.gif",
+			},
+			-1,
+		},
+		{
+			"letters are compared lexically",
+			args{
+				"a10.gif",
+				"b2.gif",
+			},
+			-1,
+		},
+		{
+			"prefix comes first",
+			args{
+				"file1",
+				"file1.gif",
+			},
+			-1,
+		},
+		{
+			"directory contents come before siblings",
+			args{
+				"dir2" + sep + "file.gif",
+				"dir2.gif",
+			},
+			-1,
+		},
+		{
+			"directories are compared by value",
+			args{
+				"dir10" + sep + "file.gif",
+				"dir9" + sep + "file.gif",
+			},
+			1,
+		},
+	}
+	for _, tt := range tests {
+		got := compareNatural(tt.args.path1, tt.args.path2)
+		assert.Equal(tt.want, got, tt.name)
+	}
+}
@@ -0,0 +1,25 @@
+package fs
+
+import (
+	"os"
+	"syscall"
+	"time"
+)
+
+// setSysStat sets the information read from the filesystem
+// that is only available on Linux.
+func (fi *FileInfo) setSysStat(info os.FileInfo) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return
+	}
+
+	fi.AccessTime = time.Unix(int64(stat.Atim.Sec), int64(stat.Atim.Nsec))
+	fi.ChangeTime = time.Unix(int64(stat.Ctim.Sec), int64(stat.Ctim.Nsec))
+	fi.UID = stat.Uid
+	fi.GID = stat.Gid
+	fi.Inode = uint64(stat.Ino)
+	fi.Dev = uint64(stat.Dev)
+	fi.Nlink = uint64(stat.Nlink)
+	fi.Blocks = int64(stat.Blocks)
+}
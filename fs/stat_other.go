@@ -0,0 +1,10 @@
+//go:build !linux
+// +build !linux
+
+package fs
+
+import "os"
+
+// setSysStat sets the information read from the filesystem
+// that is only available on Linux.
+func (fi *FileInfo) setSysStat(info os.FileInfo) {}
@@ -0,0 +1,84 @@
+package fs
+
+// DirStatsInfo represents statistics on the files in a directory.
+type DirStatsInfo struct {
+	Files int   // number of regular files
+	Bytes int64 // total size of regular files in bytes
+	Dirs  int   // number of subdirectories
+
+	Exts map[string]*ExtStats // statistics on regular files by extension
+
+	Largest *FileInfo // largest regular file, nil if there are no files
+	Oldest  *FileInfo // least recently modified regular file, nil if there are no files
+}
+
+// ExtStats represents statistics on the regular files with a given extension.
+type ExtStats struct {
+	Files int   // number of files
+	Bytes int64 // total size of files in bytes
+}
+
+// DirStats reads the directory named by the given dirname
+// following the given options and returns statistics on the files found.
+// Directories are always read, so that they can be counted,
+// and are also subject to Filter; MaxFiles and sort options are ignored.
+// Errors are handled as in ReadDir and are returned together with
+// the statistics on the files read.
+func DirStats(dirname string, options *ReadDirOptions) (*DirStatsInfo, error) {
+	if options == nil {
+		return nil, NoReadDirOptionsErr
+	}
+
+	statsOptions := *options
+	statsOptions.IncludeDirs = true
+	statsOptions.SkipStat = false
+	statsOptions.MaxFiles = 0
+	statsOptions.Sort = NoSort
+	statsOptions.DirsFirst = false
+
+	fileInfos, err := ReadDir(dirname, &statsOptions)
+	if fileInfos == nil {
+		return nil, err
+	}
+
+	stats := &DirStatsInfo{
+		Exts: make(map[string]*ExtStats),
+	}
+	for _, fi := range fileInfos {
+		stats.add(fi)
+	}
+
+	return stats, err
+}
+
+// add adds the given file to the statistics.
+func (s *DirStatsInfo) add(fi *FileInfo) {
+	if fi.IsDir() {
+		s.Dirs++
+		return
+	}
+	if !fi.IsRegular() {
+		return
+	}
+
+	s.Files++
+	s.Bytes += fi.Size
+
+	ext, ok := s.Exts[fi.Ext]
+	if !ok {
+		ext = &ExtStats{}
+		s.Exts[fi.Ext] = ext
+	}
+	ext.Files++
+	ext.Bytes += fi.Size
+
+	// Ties are broken by path order, since files are read unsorted.
+	largest := s.Largest == nil || fi.Size > s.Largest.Size ||
+		fi.Size == s.Largest.Size && comparePaths(fi.Path, s.Largest.Path) < 0
+	if largest {
+		s.Largest = fi
+	}
+	if s.Oldest == nil || compareFileInfos(fi, s.Oldest, SortByModTime) < 0 {
+		s.Oldest = fi
+	}
+}
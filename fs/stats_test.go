@@ -0,0 +1,163 @@
+package fs
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDirStats(t *testing.T) {
+	assert := assert.New(t)
+
+	dir1, err := ioutil.TempDir("", "dir")
+	assert.Nil(err)
+	defer os.RemoveAll(dir1)
+
+	dir2, err := ioutil.TempDir("", "dir")
+	assert.Nil(err)
+	defer os.RemoveAll(dir2)
+	modTime := time.Now().Add(-time.Hour)
+	assert.Nil(os.MkdirAll(filepath.Join(dir2, "a", "b"), 0755))
+	assert.Nil(writeTestFile(filepath.Join(dir2, "1.gif"), 10, modTime))
+	assert.Nil(writeTestFile(filepath.Join(dir2, "a", "2.gif"), 30, modTime.Add(-time.Minute)))
+	assert.Nil(writeTestFile(filepath.Join(dir2, "a", "3.txt"), 30, modTime))
+	assert.Nil(writeTestFile(filepath.Join(dir2, "a", "b", "4"), 5, modTime.Add(time.Minute)))
+
+	type args struct {
+		dirname string
+		options *ReadDirOptions
+	}
+	tests := []struct {
+		name        string
+		args        args
+		want        *DirStatsInfo
+		wantLargest string
+		wantOldest  string
+		wantErr     bool
+	}{
+		{
+			"invalid options",
+			args{
+				dir1,
+				nil,
+			},
+			nil,
+			"",
+			"",
+			true,
+		},
+		{
+			"invalid dir",
+			args{
+				"",
+				&ReadDirOptions{},
+			},
+			nil,
+			"",
+			"",
+			true,
+		},
+		{
+			"empty dir",
+			args{
+				dir1,
+				&ReadDirOptions{},
+			},
+			&DirStatsInfo{
+				Exts: map[string]*ExtStats{},
+			},
+			"",
+			"",
+			false,
+		},
+		{
+			"non-empty dir, exclude subdirs",
+			args{
+				dir2,
+				&ReadDirOptions{},
+			},
+			&DirStatsInfo{
+				Files: 1,
+				Bytes: 10,
+				Dirs:  1,
+				Exts: map[string]*ExtStats{
+					".gif": {Files: 1, Bytes: 10},
+				},
+			},
+			"1.gif",
+			"1.gif",
+			false,
+		},
+		{
+			"non-empty dir, include subdirs",
+			args{
+				dir2,
+				&ReadDirOptions{
+					IncludeSubdirs: true,
+					MaxFiles:       1,
+					Sort:           SortBySize,
+				},
+			},
+			&DirStatsInfo{
+				Files: 4,
+				Bytes: 75,
+				Dirs:  2,
+				Exts: map[string]*ExtStats{
+					".gif": {Files: 2, Bytes: 40},
+					".txt": {Files: 1, Bytes: 30},
+					"":     {Files: 1, Bytes: 5},
+				},
+			},
+			"2.gif",
+			"2.gif",
+			false,
+		},
+		{
+			"non-empty dir, include subdirs, min size 20 bytes",
+			args{
+				dir2,
+				&ReadDirOptions{
+					IncludeSubdirs: true,
+					MinSize:        20,
+				},
+			},
+			&DirStatsInfo{
+				Files: 2,
+				Bytes: 60,
+				Dirs:  2,
+				Exts: map[string]*ExtStats{
+					".gif": {Files: 1, Bytes: 30},
+					".txt": {Files: 1, Bytes: 30},
+				},
+			},
+			"2.gif",
+			"2.gif",
+			false,
+		},
+	}
+	for _, tt := range tests {
+		got, gotErr := DirStats(tt.args.dirname, tt.args.options)
+		assert.Equal(tt.wantErr, gotErr != nil, tt.name)
+		if tt.want == nil {
+			assert.Nil(got, tt.name)
+			continue
+		}
+
+		gotLargest, gotOldest := "", ""
+		if got.Largest != nil {
+			gotLargest = got.Largest.Name
+		}
+		if got.Oldest != nil {
+			gotOldest = got.Oldest.Name
+		}
+		assert.Equal(tt.wantLargest, gotLargest, tt.name)
+		assert.Equal(tt.wantOldest, gotOldest, tt.name)
+
+		got.Largest, got.Oldest = nil, nil
+		assert.Equal(tt.want, got, tt.name)
+	}
+}
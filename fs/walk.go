@@ -0,0 +1,164 @@
+package fs
+
+import (
+	"path/filepath"
+	"sync"
+
+	"github.com/karrick/godirwalk"
+)
+
+// direntsBatchSize is the number of directory entries
+// visited by a single goroutine when walking concurrently.
+const direntsBatchSize = 64
+
+// walkConcurrent walks the directory using the given number of workers.
+// Each directory is read by a single worker, while its entries
+// are visited in batches by different workers.
+func (r *dirReader) walkConcurrent(workers int) {
+	queue := newWalkQueue()
+	queue.push(walkTask{dirname: r.dirname})
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			// Each worker owns a scratch buffer for reading directories.
+			buffer := make([]byte, godirwalk.DefaultScratchBufferSize)
+			for {
+				task, ok := queue.pop()
+				if !ok {
+					return
+				}
+				if !r.isHalted() {
+					if task.dirents == nil {
+						r.readDirents(queue, task.dirname, buffer)
+					} else {
+						r.visitDirents(queue, task.dirname, task.dirents)
+					}
+				}
+				queue.done()
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// readDirents reads the directory with the given dirname
+// and queues its entries to be visited in batches.
+func (r *dirReader) readDirents(queue *walkQueue, dirname string, buffer []byte) {
+	dirents, err := godirwalk.ReadDirents(dirname, buffer)
+	if err != nil {
+		r.handleErr(dirname, err)
+		return
+	}
+
+	for start := 0; start < len(dirents); start += direntsBatchSize {
+		end := start + direntsBatchSize
+		if end > len(dirents) {
+			end = len(dirents)
+		}
+		queue.push(walkTask{dirname: dirname, dirents: dirents[start:end]})
+	}
+}
+
+// visitDirents visits the given entries of the directory with the given
+// dirname and queues the subdirectories to be read.
+func (r *dirReader) visitDirents(queue *walkQueue, dirname string, dirents godirwalk.Dirents) {
+	for _, de := range dirents {
+		if r.isHalted() {
+			return
+		}
+
+		osPathname := filepath.Join(dirname, de.Name())
+		_, descend, err := r.visit(osPathname, de)
+		if err != nil {
+			r.handleErr(osPathname, err)
+			continue
+		}
+
+		if descend {
+			queue.push(walkTask{dirname: osPathname})
+		}
+	}
+}
+
+// walkTask represents a directory to read or a batch of its entries to visit.
+type walkTask struct {
+	dirname string
+	dirents godirwalk.Dirents // entries to visit, or nil to read the directory
+}
+
+// walkQueue holds the tasks of a concurrent walk.
+// Tasks are taken in last-in, first-out order, which walks depth-first
+// and keeps the queue short.
+type walkQueue struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	tasks   []walkTask
+	pending int // number of tasks queued or being run
+}
+
+func newWalkQueue() *walkQueue {
+	q := &walkQueue{}
+	q.cond = sync.NewCond(&q.mu)
+	return q
+}
+
+// push adds the given task to the queue.
+func (q *walkQueue) push(task walkTask) {
+	q.mu.Lock()
+	q.tasks = append(q.tasks, task)
+	q.pending++
+	q.mu.Unlock()
+	q.cond.Signal()
+}
+
+// pop removes a task from the queue, waiting for one if the queue is empty,
+// and returns false if all tasks are done.
+func (q *walkQueue) pop() (walkTask, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for len(q.tasks) == 0 && q.pending > 0 {
+		q.cond.Wait()
+	}
+	if len(q.tasks) == 0 {
+		return walkTask{}, false
+	}
+
+	task := q.tasks[len(q.tasks)-1]
+	q.tasks[len(q.tasks)-1] = walkTask{}
+	q.tasks = q.tasks[:len(q.tasks)-1]
+	return task, true
+}
+
+// done marks a task taken from the queue as done.
+func (q *walkQueue) done() {
+	q.mu.Lock()
+	q.pending--
+	finished := q.pending == 0
+	q.mu.Unlock()
+
+	if finished {
+		q.cond.Broadcast()
+	}
+}
+
+// handleErr handles an error occurred while walking concurrently.
+func (r *dirReader) handleErr(pathname string, err error) {
+	if err == HaltErr || r.onError(pathname, err) == godirwalk.Halt {
+		r.mu.Lock()
+		r.halted = true
+		r.mu.Unlock()
+	}
+}
+
+// isHalted returns true if the concurrent walk has been halted.
+func (r *dirReader) isHalted() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.halted
+}
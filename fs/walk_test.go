@@ -0,0 +1,120 @@
+package fs
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_dirReader_walkConcurrent(t *testing.T) {
+	assert := assert.New(t)
+
+	// Enough files to be visited in multiple batches.
+	dir1, err := ioutil.TempDir("", "dir")
+	assert.Nil(err)
+	defer os.RemoveAll(dir1)
+	for _, subdir := range []string{"a", filepath.Join("a", "b"), "c"} {
+		assert.Nil(os.MkdirAll(filepath.Join(dir1, subdir), 0755))
+		for i := 0; i < 3*direntsBatchSize; i++ {
+			filename := filepath.Join(dir1, subdir, fmt.Sprintf("file%d.txt", i))
+			assert.Nil(ioutil.WriteFile(filename, make([]byte, i), defaultFilePermissions))
+		}
+	}
+
+	tests := []struct {
+		name    string
+		options ReadDirOptions
+	}{
+		{
+			"include subdirs",
+			ReadDirOptions{
+				IncludeSubdirs: true,
+			},
+		},
+		{
+			"include subdirs, include dirs, limit 100 files",
+			ReadDirOptions{
+				IncludeSubdirs: true,
+				IncludeDirs:    true,
+				MaxFiles:       100,
+			},
+		},
+		{
+			"include subdirs, max depth 2, min size 100 bytes",
+			ReadDirOptions{
+				IncludeSubdirs: true,
+				MaxDepth:       2,
+				MinSize:        100,
+			},
+		},
+		{
+			"include subdirs, sort by natural path, descending",
+			ReadDirOptions{
+				IncludeSubdirs: true,
+				Sort:           SortByNaturalPath,
+				SortDescending: true,
+			},
+		},
+		{
+			"include subdirs, follow symlinks",
+			ReadDirOptions{
+				IncludeSubdirs: true,
+				FollowSymlinks: true,
+			},
+		},
+	}
+	for _, tt := range tests {
+		sequential := tt.options
+		want, wantErr := ReadDir(dir1, &sequential)
+		assert.Nil(wantErr, tt.name)
+
+		for _, workers := range []int{2, 8} {
+			concurrent := tt.options
+			concurrent.Workers = workers
+			got, gotErr := ReadDir(dir1, &concurrent)
+			assert.Nil(gotErr, tt.name)
+			assert.Equal(withoutAccessTimes(want), withoutAccessTimes(got), tt.name)
+		}
+	}
+}
+
+func Test_dirReader_walkConcurrent_goroutines(t *testing.T) {
+	assert := assert.New(t)
+
+	// Many subdirectories must not start a goroutine each.
+	dir1, err := ioutil.TempDir("", "dir")
+	assert.Nil(err)
+	defer os.RemoveAll(dir1)
+	for i := 0; i < 2000; i++ {
+		assert.Nil(os.Mkdir(filepath.Join(dir1, fmt.Sprintf("dir%d", i)), 0755))
+	}
+
+	const workers = 2
+	baseline := runtime.NumGoroutine()
+	var mu sync.Mutex
+	peak := 0
+	options := &ReadDirOptions{
+		IncludeSubdirs: true,
+		IncludeDirs:    true,
+		Workers:        workers,
+		Filter: func(fi *FileInfo) bool {
+			mu.Lock()
+			defer mu.Unlock()
+			if n := runtime.NumGoroutine(); n > peak {
+				peak = n
+			}
+			return true
+		},
+	}
+
+	fileInfos, err := ReadDir(dir1, options)
+	assert.Nil(err)
+	assert.Len(fileInfos, 2000)
+	assert.True(peak <= baseline+workers, "peak of %d goroutines", peak)
+}